@@ -2,20 +2,42 @@
 package main
 
 import (
+	"bufio"
+	"flag"
+	"fmt"
 	"github.com/phelix-/psostats/v2/pkg/model"
+	"golang.org/x/crypto/ssh/terminal"
 	"log"
 	"os"
-	"syscall"
+	"strings"
 
 	"github.com/phelix-/psostats/v2/client/internal/client"
 )
 
-var (
-	kernel32         = syscall.MustLoadDLL("kernel32.dll")
-	procSetStdHandle = kernel32.MustFindProc("SetStdHandle")
-)
-
 func main() {
+	replay := flag.String("replay", "", "replay a recorded .psotrace file and write its quest runs to json")
+	headless := flag.Bool("headless", false, "run without the console ui, writing events as json lines to stdout or eventLog")
+	login := flag.Bool("login", false, "exchange your password for an api token so it doesn't need to be kept in config.yaml")
+	listTokens := flag.Bool("tokens", false, "list the api tokens issued to your account")
+	revoke := flag.String("revoke", "", "revoke the api token with this id")
+	flag.Parse()
+	if len(*replay) > 0 {
+		replayTrace(*replay)
+		return
+	}
+	if *login {
+		loginWithPassword()
+		return
+	}
+	if *listTokens {
+		printApiTokens()
+		return
+	}
+	if len(*revoke) > 0 {
+		revokeApiToken(*revoke)
+		return
+	}
+
 	file, err := os.OpenFile("psostats.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Fatal(err)
@@ -33,30 +55,60 @@ func main() {
 	redirectStderr(file)
 	log.Printf("Starting Up version %v", version)
 
-	c, err := client.New(version)
+	c, err := client.New(version, *headless)
 	if err != nil {
 		log.Fatalf("Failed to initialize client: %v", err)
 	}
-	c.Run()
+	if err := c.Run(); err != nil {
+		log.Printf("Client stopped %v", err)
+	}
+}
+
+func replayTrace(tracePath string) {
+	runs, err := client.ReplayTrace(tracePath)
+	fmt.Printf("Replayed %v quest runs from %v\n", runs, tracePath)
+	if err != nil {
+		fmt.Printf("Replay stopped early: %v\n", err)
+		os.Exit(1)
+	}
 }
 
-func setStdHandle(stdhandle int32, handle syscall.Handle) error {
-	r0, _, e1 := syscall.Syscall(procSetStdHandle.Addr(), 2, uintptr(stdhandle), uintptr(handle), 0)
-	if r0 == 0 {
-		if e1 != 0 {
-			return error(e1)
-		}
-		return syscall.EINVAL
+func loginWithPassword() {
+	fmt.Print("User: ")
+	user, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Printf("Unable to read user: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print("Password: ")
+	password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		fmt.Printf("Unable to read password: %v\n", err)
+		os.Exit(1)
 	}
-	return nil
+	if err := client.Login(strings.TrimSpace(user), string(password)); err != nil {
+		fmt.Printf("Login failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Logged in, the password can be removed from config.yaml")
 }
 
-// redirectStderr to the file passed in
-func redirectStderr(f *os.File) {
-	err := setStdHandle(syscall.STD_ERROR_HANDLE, syscall.Handle(f.Fd()))
+func printApiTokens() {
+	tokens, err := client.ListApiTokens()
 	if err != nil {
-		log.Fatalf("Failed to redirect stderr to file: %v", err)
+		fmt.Printf("Unable to list api tokens: %v\n", err)
+		os.Exit(1)
+	}
+	for _, token := range tokens {
+		fmt.Printf("%v  %v  %v\n", token.Id, token.Created.Format("2006-01-02 15:04"), token.Name)
+	}
+}
+
+func revokeApiToken(tokenId string) {
+	if err := client.RevokeApiToken(tokenId); err != nil {
+		fmt.Printf("Unable to revoke api token: %v\n", err)
+		os.Exit(1)
 	}
-	// SetStdHandle does not affect prior references to stderr
-	os.Stderr = f
+	fmt.Printf("Revoked %v\n", tokenId)
 }
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// redirectStderr to the file passed in
+func redirectStderr(f *os.File) {
+	err := unix.Dup2(int(f.Fd()), int(os.Stderr.Fd()))
+	if err != nil {
+		log.Fatalf("Failed to redirect stderr to file: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+// Keeps every completed quest run on disk, with an index for browsing and local pb comparisons
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+const (
+	indexFile   = "index.json"
+	runFileGlob = "*.json.gz"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Entry is what the index keeps about each archived run
+type Entry struct {
+	File       string
+	QuestName  string
+	Episode    uint16
+	Difficulty string
+	Players    int
+	PbCategory bool
+	Complete   bool
+	Start      time.Time
+	Duration   time.Duration
+	DeathCount int
+	Splits     []model.QuestRunSplit
+}
+
+func (e Entry) Category() string {
+	if e.PbCategory {
+		return fmt.Sprintf("%vp PB", e.Players)
+	}
+	return fmt.Sprintf("%vp No-PB", e.Players)
+}
+
+type Archive struct {
+	dir     string
+	lock    sync.Mutex
+	entries []Entry
+}
+
+// Open loads the archive in dir, creating it if needed. A missing or unreadable index is rebuilt from the runs.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	archive := &Archive{dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err == nil {
+		err = json.Unmarshal(data, &archive.entries)
+	}
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Archive index unreadable, rebuilding: %v", err)
+		}
+		if err := archive.rebuildIndex(); err != nil {
+			return nil, fmt.Errorf("Open: rebuilding index: %w", err)
+		}
+	}
+	return archive, nil
+}
+
+func (a *Archive) rebuildIndex() error {
+	files, err := filepath.Glob(filepath.Join(a.dir, runFileGlob))
+	if err != nil {
+		return err
+	}
+	a.entries = make([]Entry, 0, len(files))
+	for _, file := range files {
+		run, err := a.load(filepath.Base(file))
+		if err != nil {
+			log.Printf("Skipping unreadable archived run %v: %v", file, err)
+			continue
+		}
+		a.entries = append(a.entries, newEntry(filepath.Base(file), run))
+	}
+	return a.writeIndex()
+}
+
+func newEntry(file string, run pso.QuestRun) Entry {
+	duration := time.Duration(0)
+	if run.QuestComplete {
+		duration = run.QuestEndTime.Sub(run.QuestStartTime)
+	}
+	return Entry{
+		File:       file,
+		QuestName:  run.QuestName,
+		Episode:    run.Episode,
+		Difficulty: run.Difficulty,
+		Players:    len(run.AllPlayers),
+		PbCategory: run.PbCategory,
+		Complete:   run.QuestComplete,
+		Start:      run.QuestStartTime,
+		Duration:   duration,
+		DeathCount: run.DeathCount,
+		Splits:     run.Splits,
+	}
+}
+
+// Save writes run to its own gzipped json file and adds it to the index
+func (a *Archive) Save(run pso.QuestRun) (Entry, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(run.QuestName, "_"), "_")
+	file := fmt.Sprintf("%v-%v.json.gz", run.QuestStartTime.Format("2006_01_02-150405"), name)
+	for i := 2; a.exists(file); i++ {
+		file = fmt.Sprintf("%v-%v-%d.json.gz", run.QuestStartTime.Format("2006_01_02-150405"), name, i)
+	}
+	if err := writeAtomically(filepath.Join(a.dir, file), func(f *os.File) error {
+		gzipWriter := gzip.NewWriter(f)
+		if err := json.NewEncoder(gzipWriter).Encode(run); err != nil {
+			return err
+		}
+		return gzipWriter.Close()
+	}); err != nil {
+		return Entry{}, fmt.Errorf("Save: %w", err)
+	}
+	entry := newEntry(file, run)
+	a.entries = append(a.entries, entry)
+	if err := a.writeIndex(); err != nil {
+		return entry, fmt.Errorf("Save: writing index: %w", err)
+	}
+	return entry, nil
+}
+
+func (a *Archive) exists(file string) bool {
+	_, err := os.Stat(filepath.Join(a.dir, file))
+	return err == nil
+}
+
+func (a *Archive) writeIndex() error {
+	return writeAtomically(filepath.Join(a.dir, indexFile), func(f *os.File) error {
+		return json.NewEncoder(f).Encode(a.entries)
+	})
+}
+
+// Load reads the full run behind an index entry
+func (a *Archive) Load(entry Entry) (pso.QuestRun, error) {
+	return a.load(entry.File)
+}
+
+func (a *Archive) load(file string) (pso.QuestRun, error) {
+	run := pso.QuestRun{}
+	f, err := os.Open(filepath.Join(a.dir, file))
+	if err != nil {
+		return run, err
+	}
+	defer f.Close()
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return run, err
+	}
+	err = json.NewDecoder(gzipReader).Decode(&run)
+	return run, err
+}
+
+// Runs lists every archived run, newest first
+func (a *Archive) Runs() []Entry {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	runs := append([]Entry(nil), a.entries...)
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Start.After(runs[j].Start)
+	})
+	return runs
+}
+
+// Quest lists the archived runs of one quest in a category, newest first
+func (a *Archive) Quest(questName string, players int, pbCategory bool) []Entry {
+	runs := make([]Entry, 0)
+	for _, entry := range a.Runs() {
+		if entry.QuestName == questName && entry.Players == players && entry.PbCategory == pbCategory {
+			runs = append(runs, entry)
+		}
+	}
+	return runs
+}
+
+// Pb is the fastest completed run of a quest in a category
+func (a *Archive) Pb(questName string, players int, pbCategory bool) (Entry, bool) {
+	pb := Entry{}
+	found := false
+	for _, entry := range a.Quest(questName, players, pbCategory) {
+		if entry.Complete && (!found || entry.Duration < pb.Duration) {
+			pb = entry
+			found = true
+		}
+	}
+	return pb, found
+}
+
+// BestSegments is the fastest time archived for each split of a quest in a category, zero where no run finished
+// the split. Only completed runs are archived, so splits finished in runs that were reset don't count.
+func (a *Archive) BestSegments(questName string, players int, pbCategory bool) []time.Duration {
+	best := make([]time.Duration, 0)
+	for _, entry := range a.Quest(questName, players, pbCategory) {
+		for i, split := range entry.Splits {
+			if split.Start.IsZero() {
+				continue
+			}
+			end := split.End
+			if end.IsZero() {
+				lastSplit := i == len(entry.Splits)-1 || entry.Splits[i+1].Start.IsZero()
+				if !entry.Complete || !lastSplit {
+					continue
+				}
+				// The final split ends with the quest
+				end = entry.Start.Add(entry.Duration)
+			}
+			segment := end.Sub(split.Start)
+			if segment <= 0 {
+				continue
+			}
+			for len(best) <= i {
+				best = append(best, 0)
+			}
+			if best[i] == 0 || segment < best[i] {
+				best[i] = segment
+			}
+		}
+	}
+	return best
+}
+
+func writeAtomically(path string, write func(f *os.File) error) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if err := write(temp); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,150 @@
+package archive_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/archive"
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/player"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+var testStart = time.Date(2022, 3, 14, 20, 0, 0, 0, time.UTC)
+
+func testRun(questName string, startOffset time.Duration, duration time.Duration, players int) pso.QuestRun {
+	start := testStart.Add(startOffset)
+	run := pso.QuestRun{
+		QuestName:      questName,
+		Episode:        1,
+		Difficulty:     "Ultimate",
+		AllPlayers:     make([]player.BasePlayerInfo, players),
+		PbCategory:     true,
+		QuestStartTime: start,
+		QuestEndTime:   start.Add(duration),
+		QuestComplete:  duration > 0,
+		Splits: []model.QuestRunSplit{
+			{Name: "Forest", Start: start, End: start.Add(duration / 2)},
+			{Name: "Boss", Start: start.Add(duration / 2), End: start.Add(duration)},
+		},
+	}
+	return run
+}
+
+func TestArchive_SaveAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	runArchive, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := runArchive.Save(testRun("Mop-up Operation #1", 0, 5*time.Minute, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Category() != "4p PB" || entry.Duration != 5*time.Minute || !entry.Complete {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	reopened, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := reopened.Runs()
+	if len(runs) != 1 || runs[0].File != entry.File {
+		t.Fatalf("Expected the saved run in the index but got %+v", runs)
+	}
+	run, err := reopened.Load(runs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.QuestName != "Mop-up Operation #1" || len(run.Splits) != 2 || !run.QuestEndTime.Equal(testStart.Add(5*time.Minute)) {
+		t.Errorf("Unexpected run %+v", run)
+	}
+}
+
+func TestArchive_RebuildsMissingIndex(t *testing.T) {
+	dir := t.TempDir()
+	runArchive, _ := archive.Open(dir)
+	runArchive.Save(testRun("Mop-up Operation #1", 0, 5*time.Minute, 1))
+	runArchive.Save(testRun("Mop-up Operation #1", time.Hour, 4*time.Minute, 1))
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := rebuilt.Runs(); len(runs) != 2 {
+		t.Errorf("Expected 2 runs after rebuilding the index but got %+v", runs)
+	}
+}
+
+func TestArchive_SameStartGetsItsOwnFile(t *testing.T) {
+	runArchive, _ := archive.Open(t.TempDir())
+	first, _ := runArchive.Save(testRun("Mop-up Operation #1", 0, 5*time.Minute, 1))
+	second, _ := runArchive.Save(testRun("Mop-up Operation #1", 0, 5*time.Minute, 1))
+	if first.File == second.File {
+		t.Errorf("Expected two files but both runs were saved to %v", first.File)
+	}
+}
+
+func TestArchive_RunsNewestFirst(t *testing.T) {
+	runArchive, _ := archive.Open(t.TempDir())
+	runArchive.Save(testRun("Mop-up Operation #1", time.Hour, 5*time.Minute, 1))
+	runArchive.Save(testRun("Mop-up Operation #2", 3*time.Hour, 5*time.Minute, 1))
+	runArchive.Save(testRun("Mop-up Operation #3", 2*time.Hour, 5*time.Minute, 1))
+	runs := runArchive.Runs()
+	for i, expected := range []string{"Mop-up Operation #2", "Mop-up Operation #3", "Mop-up Operation #1"} {
+		if runs[i].QuestName != expected {
+			t.Errorf("Expected run %v to be %v but got %v", i, expected, runs[i].QuestName)
+		}
+	}
+}
+
+func TestArchive_Pb(t *testing.T) {
+	runArchive, _ := archive.Open(t.TempDir())
+	runArchive.Save(testRun("Mop-up Operation #1", 0, 5*time.Minute, 1))
+	runArchive.Save(testRun("Mop-up Operation #1", time.Hour, 4*time.Minute, 1))
+	runArchive.Save(testRun("Mop-up Operation #1", 2*time.Hour, 0, 1))
+	runArchive.Save(testRun("Mop-up Operation #1", 3*time.Hour, 3*time.Minute, 4))
+	runArchive.Save(testRun("Mop-up Operation #2", 4*time.Hour, 2*time.Minute, 1))
+
+	pb, found := runArchive.Pb("Mop-up Operation #1", 1, true)
+	if !found || pb.Duration != 4*time.Minute || pb.Splits[1].End.Sub(pb.Splits[1].Start) != 2*time.Minute {
+		t.Errorf("Expected the 4 minute solo run but got %+v %v", pb, found)
+	}
+	if _, found := runArchive.Pb("Mop-up Operation #1", 1, false); found {
+		t.Error("Expected no pb in the No-PB category")
+	}
+}
+
+func TestArchive_BestSegments(t *testing.T) {
+	runArchive, _ := archive.Open(t.TempDir())
+	slowForest := testRun("Mop-up Operation #1", 0, 5*time.Minute, 1)
+	slowForest.Splits[0].End = slowForest.QuestStartTime.Add(4 * time.Minute)
+	slowForest.Splits[1].Start = slowForest.Splits[0].End
+	runArchive.Save(slowForest)
+	runArchive.Save(testRun("Mop-up Operation #1", time.Hour, 6*time.Minute, 1))
+	// Abandoned in the boss split, its forest split still counts
+	abandoned := testRun("Mop-up Operation #1", 2*time.Hour, 2*time.Minute, 1)
+	abandoned.QuestComplete = false
+	abandoned.Splits[1].End = time.Time{}
+	runArchive.Save(abandoned)
+	// The final split of a complete run ends with the quest
+	unendedBoss := testRun("Mop-up Operation #1", 3*time.Hour, 5*time.Minute, 1)
+	unendedBoss.Splits[1].End = time.Time{}
+	unendedBoss.Splits[1].Start = unendedBoss.QuestStartTime.Add(4*time.Minute + 30*time.Second)
+	runArchive.Save(unendedBoss)
+	runArchive.Save(testRun("Mop-up Operation #1", 4*time.Hour, time.Minute, 4))
+
+	best := runArchive.BestSegments("Mop-up Operation #1", 1, true)
+	if len(best) != 2 || best[0] != time.Minute || best[1] != 30*time.Second {
+		t.Errorf("Expected best segments of 1m and 30s but got %v", best)
+	}
+	if best := runArchive.BestSegments("Mop-up Operation #2", 1, true); len(best) != 0 {
+		t.Errorf("Expected no best segments for an unplayed quest but got %v", best)
+	}
+}
@@ -0,0 +1,171 @@
+// Counts quest attempts, resets and where they happened, per quest and category
+package attempts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+)
+
+// Stats are the attempts at one quest in one category
+type Stats struct {
+	QuestName  string
+	Players    int
+	PbCategory bool
+	Attempts   int
+	Finished   int
+	Resets     int
+	// Resets by the split that was running, resets before the first split or in quests without splits aren't here
+	ResetSplits map[string]int `json:",omitempty"`
+	LastAttempt time.Time
+}
+
+// FinishedRatio is the share of started attempts that were finished
+func (s Stats) FinishedRatio() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Finished) / float64(s.Attempts)
+}
+
+type Tracker struct {
+	path       string
+	lock       sync.Mutex
+	stats      []Stats
+	inProgress bool
+	// Holds a pending save for Run, events arrive on the memory reading goroutine which shouldn't wait on the disk
+	saves chan struct{}
+}
+
+// Open loads the attempts saved at path, starting fresh if there's no file yet. Counts are saved by Run.
+func Open(path string) (*Tracker, error) {
+	tracker := &Tracker{path: path, saves: make(chan struct{}, 1)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tracker, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	if err := json.Unmarshal(data, &tracker.stats); err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	return tracker, nil
+}
+
+// Stats for a quest in a category, empty if it's never been attempted
+func (t *Tracker) Stats(questName string, players int, pbCategory bool) Stats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if i := t.find(questName, players, pbCategory); i >= 0 {
+		stats := t.stats[i]
+		stats.ResetSplits = make(map[string]int, len(t.stats[i].ResetSplits))
+		for split, resets := range t.stats[i].ResetSplits {
+			stats.ResetSplits[split] = resets
+		}
+		return stats
+	}
+	return Stats{QuestName: questName, Players: players, PbCategory: pbCategory}
+}
+
+func (t *Tracker) find(questName string, players int, pbCategory bool) int {
+	for i, stats := range t.stats {
+		if stats.QuestName == questName && stats.Players == players && stats.PbCategory == pbCategory {
+			return i
+		}
+	}
+	return -1
+}
+
+// SendEvent counts quest starts, completions and resets, it's a pso.QuestEventListener.
+// Leaving a completed quest also sends a reset, only resets of unfinished attempts are counted.
+func (t *Tracker) SendEvent(event pso.QuestEvent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	i := t.find(event.QuestName, event.Players, event.PbCategory)
+	if i < 0 {
+		if event.Type != pso.QuestStartEvent {
+			return
+		}
+		t.stats = append(t.stats, Stats{QuestName: event.QuestName, Players: event.Players, PbCategory: event.PbCategory})
+		i = len(t.stats) - 1
+	}
+	stats := &t.stats[i]
+	switch event.Type {
+	case pso.QuestStartEvent:
+		stats.Attempts++
+		stats.LastAttempt = event.Time
+		t.inProgress = true
+	case pso.QuestCompleteEvent:
+		if !t.inProgress {
+			return
+		}
+		stats.Finished++
+		t.inProgress = false
+	case pso.ResetEvent:
+		if !t.inProgress {
+			return
+		}
+		stats.Resets++
+		if event.Split != nil {
+			if stats.ResetSplits == nil {
+				stats.ResetSplits = make(map[string]int)
+			}
+			stats.ResetSplits[event.Split.Name]++
+		}
+		t.inProgress = false
+	default:
+		return
+	}
+	// a save that's already pending will pick this change up too
+	select {
+	case t.saves <- struct{}{}:
+	default:
+	}
+}
+
+// Run saves the counts after each change until done is closed, then saves any change still pending
+func (t *Tracker) Run(done <-chan struct{}) {
+	for {
+		select {
+		case <-t.saves:
+			t.saveAndLog()
+		case <-done:
+			select {
+			case <-t.saves:
+				t.saveAndLog()
+			default:
+			}
+			return
+		}
+	}
+}
+
+func (t *Tracker) saveAndLog() {
+	if err := t.save(); err != nil {
+		log.Printf("Unable to save quest attempts %v", err)
+	}
+}
+
+func (t *Tracker) save() error {
+	t.lock.Lock()
+	jsonBytes, err := json.Marshal(t.stats)
+	t.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	temp := t.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(temp, jsonBytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, t.path)
+}
@@ -0,0 +1,87 @@
+package attempts_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/attempts"
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+func openTracker(path string, t *testing.T) *attempts.Tracker {
+	tracker, err := attempts.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker
+}
+
+// runTracker saves tracker's counts in the background until the returned stop is called
+func runTracker(tracker *attempts.Tracker) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		tracker.Run(done)
+		close(stopped)
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func questEvent(eventType pso.QuestEventType, players int, split string) pso.QuestEvent {
+	event := pso.QuestEvent{
+		Type:       eventType,
+		Time:       time.Date(2022, 3, 14, 20, 0, 0, 0, time.UTC),
+		QuestName:  "Maximum Attack E: Forest",
+		Players:    players,
+		PbCategory: true,
+	}
+	if len(split) > 0 {
+		event.Split = &model.QuestRunSplit{Name: split}
+	}
+	return event
+}
+
+func TestTracker_CountsAttemptsAndResets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attempts.json")
+	tracker := openTracker(path, t)
+	stop := runTracker(tracker)
+	tracker.SendEvent(questEvent(pso.QuestStartEvent, 1, ""))
+	tracker.SendEvent(questEvent(pso.ResetEvent, 1, "Forest 2"))
+	tracker.SendEvent(questEvent(pso.QuestStartEvent, 1, ""))
+	tracker.SendEvent(questEvent(pso.ResetEvent, 1, ""))
+	tracker.SendEvent(questEvent(pso.QuestStartEvent, 1, ""))
+	tracker.SendEvent(questEvent(pso.SplitEvent, 1, "Forest 1"))
+	tracker.SendEvent(questEvent(pso.QuestCompleteEvent, 1, ""))
+	// Leaving the finished quest
+	tracker.SendEvent(questEvent(pso.ResetEvent, 1, "Boss"))
+	tracker.SendEvent(questEvent(pso.QuestStartEvent, 4, ""))
+	stop()
+
+	stats := openTracker(path, t).Stats("Maximum Attack E: Forest", 1, true)
+	if stats.Attempts != 3 || stats.Finished != 1 || stats.Resets != 2 {
+		t.Errorf("Expected 3 attempts, 1 finished and 2 resets but got %+v", stats)
+	}
+	if len(stats.ResetSplits) != 1 || stats.ResetSplits["Forest 2"] != 1 {
+		t.Errorf("Expected one reset in Forest 2 but got %v", stats.ResetSplits)
+	}
+	if ratio := stats.FinishedRatio(); ratio < 0.33 || ratio > 0.34 {
+		t.Errorf("Expected a third of attempts finished but got %v", ratio)
+	}
+	if fourPlayer := tracker.Stats("Maximum Attack E: Forest", 4, true); fourPlayer.Attempts != 1 || fourPlayer.Resets != 0 {
+		t.Errorf("Expected the 4p attempt counted separately but got %+v", fourPlayer)
+	}
+}
+
+func TestTracker_UnattemptedQuest(t *testing.T) {
+	tracker := openTracker(filepath.Join(t.TempDir(), "attempts.json"), t)
+	tracker.SendEvent(questEvent(pso.ResetEvent, 1, "Forest 1"))
+	stats := tracker.Stats("Maximum Attack E: Forest", 1, true)
+	if stats.Attempts != 0 || stats.Resets != 0 || stats.FinishedRatio() != 0 {
+		t.Errorf("Expected no attempts but got %+v", stats)
+	}
+}
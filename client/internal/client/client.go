@@ -4,6 +4,7 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gizak/termui/v3/widgets"
 	"io/fs"
@@ -12,75 +13,171 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/hishboy/gocommons/lang"
-
 	"github.com/phelix-/psostats/v2/pkg/model"
 
 	termui "github.com/gizak/termui/v3"
+	"github.com/phelix-/psostats/v2/client/internal/archive"
+	"github.com/phelix-/psostats/v2/client/internal/attempts"
 	"github.com/phelix-/psostats/v2/client/internal/client/config"
 	"github.com/phelix-/psostats/v2/client/internal/consoleui"
+	"github.com/phelix-/psostats/v2/client/internal/credentials"
+	"github.com/phelix-/psostats/v2/client/internal/eventlog"
+	"github.com/phelix-/psostats/v2/client/internal/live"
+	"github.com/phelix-/psostats/v2/client/internal/livesplit"
 	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
+	"github.com/phelix-/psostats/v2/client/internal/upload"
 )
 
+// Quest definitions kept next to config.yaml, merged with the built in quests
+var questDefinitionFiles = []string{"./quests.yaml", "./quests.json"}
+
+const configFile = "./config.yaml"
+
 type Client struct {
-	pso           *pso.PSO
-	clientInfo    model.ClientInfo
-	httpClient    http.Client
+	pso        *pso.PSO
+	clientInfo model.ClientInfo
+	httpClient http.Client
+	configLock sync.RWMutex
+	// config.yaml as read, and with the profile for the connected pso server applied
+	baseConfig    *config.Config
 	config        *config.Config
-	uiRefreshRate time.Duration
+	profile       string
+	server        string
 	ui            *consoleui.ConsoleUI
 	currentGameId int
 	errChan       chan error
 	done          chan struct{}
 	startedGame   chan pso.QuestRun
 	completeGame  chan pso.QuestRun
-	gameQueue     *lang.Queue
+	// Games from profiles with autoUpload on and off, one of them is the queue in uploadQueueDir
+	autoQueue   *upload.Queue
+	manualQueue *upload.Queue
+	archive     *archive.Archive
+	attempts    *attempts.Tracker
+	credentials *credentials.Store
+	showArchive bool
+	archiveRow  int
+	liveServer  *live.Server
+	liveSplit   *livesplit.Client
+	// Only set in headless mode, where it replaces the console ui
+	events *eventlog.Log
 }
 
-func New(clientInfo model.ClientInfo) (*Client, error) {
-	ui, err := consoleui.New(clientInfo)
-	if err != nil {
-		log.Fatalf("failed to initialize termui: %v", err)
-	}
+// New sets up the client, headless skips the console ui in favour of an event log. The headless config
+// setting turns it on as well.
+func New(clientInfo model.ClientInfo, headless bool) (*Client, error) {
 	startedGameChannel := make(chan pso.QuestRun)
 	completeGameChannel := make(chan pso.QuestRun)
-	pso := pso.New(startedGameChannel, completeGameChannel)
-	clientConfig, err := config.ReadFromFile("./config.yaml")
+	clientConfig, err := config.ReadFromFile(configFile)
 	if err != nil {
-		if pathErr, ok := err.(*fs.PathError); ok && pathErr.Err == syscall.ERROR_FILE_NOT_FOUND {
-			showMissingConfigUi(ui)
+		if errors.Is(err, fs.ErrNotExist) {
+			if !headless {
+				showConfigErrorUi(clientInfo, "Config file missing. Press any key to quit")
+			}
 			log.Fatalf("Missing config file, shutting down")
 		} else {
+			if !headless {
+				showConfigErrorUi(clientInfo, fmt.Sprintf("Unable to read %v: %v\n\nPress any key to quit", configFile, err))
+			}
 			log.Fatalf("Unable to start client %v", err)
 		}
 	}
+	var ui *consoleui.ConsoleUI
+	var events *eventlog.Log
+	if headless || clientConfig.HeadlessEnabled() {
+		ui = consoleui.NewHeadless(clientInfo)
+		events, err = eventlog.Open(clientConfig.GetEventLog())
+		if err != nil {
+			log.Fatalf("Unable to open event log %v", err)
+		}
+	} else {
+		ui, err = consoleui.New(clientInfo)
+		if err != nil {
+			log.Fatalf("failed to initialize termui: %v", err)
+		}
+	}
+	offsetProfiles, err := offsets.Load("./offsets.yaml")
+	if err != nil {
+		log.Fatalf("Unable to load memory offsets %v", err)
+	}
+	pso := pso.New(startedGameChannel, completeGameChannel)
+	pso.SetOffsetProfiles(offsetProfiles, clientConfig.GetOffsetBuild())
+	pso.SetDataFrameRate(clientConfig.GetDataFrameRate())
+	ui.QuestWarnings = pso.LoadQuestDefinitions(questDefinitionFiles...)
+	if clientConfig.RecordTraceEnabled() {
+		pso.RecordTraces(".")
+	}
+	runArchive, err := archive.Open(clientConfig.GetArchiveDir())
+	if err != nil {
+		log.Printf("Unable to open run archive, runs won't be saved locally %v", err)
+	}
+	attemptTracker, err := attempts.Open(filepath.Join(clientConfig.GetArchiveDir(), "attempts.json"))
+	if err != nil {
+		log.Printf("Unable to open quest attempts, attempts won't be counted %v", err)
+	} else {
+		pso.AddQuestEventListener(attemptTracker.SendEvent)
+		ui.Attempts = attemptTracker
+	}
+	credentialStore, err := credentials.Open(credentialsFile)
+	if err != nil {
+		log.Printf("Unable to open saved api tokens, passwords will be sent instead %v", err)
+	}
+	autoQueue, manualQueue, err := openUploadQueues(clientConfig)
+	if err != nil {
+		log.Fatalf("Unable to open upload queue %v", err)
+	}
+	ui.UploadQueue = autoQueue
+	if !clientConfig.AutoUploadEnabled() {
+		ui.UploadQueue = manualQueue
+	}
 
-	return &Client{
-		pso:           pso,
-		clientInfo:    clientInfo,
-		httpClient:    http.Client{},
-		config:        clientConfig,
-		uiRefreshRate: clientConfig.GetUiRefreshRate(),
-		ui:            ui,
-		errChan:       make(chan error),
-		done:          make(chan struct{}),
-		startedGame:   startedGameChannel,
-		completeGame:  completeGameChannel,
-		gameQueue:     lang.NewQueue(),
-	}, nil
+	c := &Client{
+		pso:          pso,
+		clientInfo:   clientInfo,
+		httpClient:   http.Client{},
+		baseConfig:   clientConfig,
+		config:       clientConfig,
+		ui:           ui,
+		errChan:      make(chan error),
+		done:         make(chan struct{}),
+		startedGame:  startedGameChannel,
+		completeGame: completeGameChannel,
+		autoQueue:    autoQueue,
+		manualQueue:  manualQueue,
+		archive:      runArchive,
+		attempts:     attemptTracker,
+		credentials:  credentialStore,
+		events:       events,
+	}
+	if events != nil {
+		pso.AddQuestEventListener(events.SendEvent)
+	}
+	if len(clientConfig.GetLiveServerAddress()) > 0 {
+		c.liveServer = live.New(c.liveSnapshot, clientConfig.GetUiRefreshRate(), clientConfig.GetLiveServerOrigins())
+		pso.AddQuestEventListener(c.liveServer.SendEvent)
+	}
+	if len(clientConfig.GetLiveSplitAddress()) > 0 {
+		c.liveSplit = livesplit.New(clientConfig.GetLiveSplitAddress())
+		pso.AddQuestEventListener(c.liveSplit.SendEvent)
+	}
+	return c, nil
 }
 
 func (c *Client) Run() error {
-	defer c.ui.Close()
-
-	if err := c.getMotd(); err != nil {
-		c.ui.Motd = fmt.Sprintf("Error getting message of the day %v", err)
+	if c.events != nil {
+		return c.runHeadless()
 	}
-
-	c.pso.StartPersistentConnection(c.errChan)
+	defer c.ui.Close()
+	c.start()
+	defer c.stop()
 	go c.runUI()
 
 	uiEvents := termui.PollEvents()
@@ -94,36 +191,34 @@ func (c *Client) Run() error {
 			case "w":
 				c.writeGameJson()
 			case "u":
-				for c.gameQueue.Len() > 0 {
-					game, ok := c.gameQueue.Poll().(*pso.QuestRun)
-					if ok {
-						c.uploadGame(*game)
-					} else {
-						log.Printf("Manual upload failed")
-						break
-					}
-
-				}
 				c.pso.GameState.AwaitingUpload = false
+				c.autoQueue.RetryNow()
+				go c.uploadAll()
+			case "a":
+				c.showArchive = !c.showArchive && c.archive != nil
+				c.archiveRow = 0
+				c.ui.ClearScreen()
+			case "<Up>", "k":
+				if c.showArchive && c.archiveRow > 0 {
+					c.archiveRow--
+				}
+			case "<Down>", "j":
+				if c.showArchive && c.archiveRow < len(c.archive.Runs())-1 {
+					c.archiveRow++
+				}
+			case "1", "2", "3", "4", "5":
+				c.ui.SetPage(consoleui.Page(e.ID[0] - '1'))
+				c.ui.ClearScreen()
+			case "<Tab>":
+				c.ui.NextPage()
+				c.ui.ClearScreen()
 			case "<Resize>":
 				c.ui.ClearScreen()
 			}
 		case game := <-c.startedGame:
-			if c.config.GetQuestSplitsEnabled() && c.config.GetQuestSplitsCompareTo() != "none" {
-				go func() {
-					err := c.getQuestSplits(game.QuestName, len(game.AllPlayers), game.PbCategory)
-					if err != nil {
-						log.Printf("Error getting quest splits %v", err)
-					}
-				}()
-			}
+			c.questStarted(game)
 		case game := <-c.completeGame:
-			if c.config.AutoUploadEnabled() {
-				c.uploadGame(game)
-			} else {
-				c.gameQueue.Push(&game)
-				c.pso.GameState.AwaitingUpload = true
-			}
+			c.questCompleted(game)
 		case err := <-c.errChan:
 			close(c.done)
 			return fmt.Errorf("run: error returned on error channel %w", err)
@@ -131,13 +226,211 @@ func (c *Client) Run() error {
 	}
 }
 
-func showMissingConfigUi(cui *consoleui.ConsoleUI) {
+// runHeadless logs connection changes, quest events and uploads until SIGINT or SIGTERM
+func (c *Client) runHeadless() error {
+	defer c.events.Close()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	c.start()
+	defer c.stop()
+
+	connected, status := false, ""
+	refreshRate := c.getConfig().GetUiRefreshRate()
+	ticker := time.NewTicker(refreshRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// uiFps can change while running
+			if rate := c.getConfig().GetUiRefreshRate(); rate != refreshRate {
+				refreshRate = rate
+				ticker.Reset(refreshRate)
+			}
+			nowConnected, nowStatus := c.pso.CheckConnection()
+			if nowConnected {
+				c.selectProfile(c.pso.Server())
+			}
+			if nowConnected != connected || (nowConnected && nowStatus != status) {
+				c.events.Connection(nowConnected, nowStatus)
+			}
+			connected, status = nowConnected, nowStatus
+		case sig := <-signals:
+			log.Printf("Got %v, shutting down", sig)
+			close(c.done)
+			c.events.Stopped(nil)
+			return nil
+		case game := <-c.startedGame:
+			c.questStarted(game)
+		case game := <-c.completeGame:
+			c.questCompleted(game)
+		case err := <-c.errChan:
+			close(c.done)
+			err = fmt.Errorf("runHeadless: error returned on error channel %w", err)
+			c.events.Stopped(err)
+			return err
+		}
+	}
+}
+
+// getConfig is the current config with the profile for the connected server applied, it's replaced whenever
+// config.yaml changes or we connect to another server
+func (c *Client) getConfig() *config.Config {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	return c.config
+}
+
+// configForServer is the current config with the profile for server applied, games are uploaded with the
+// profile of the server they were played on
+func (c *Client) configForServer(server string) *config.Config {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	serverConfig, _ := c.baseConfig.ForServer(server)
+	return serverConfig
+}
+
+// reloadConfig swaps in a changed config. Settings read per request or per frame take effect straight away,
+// the rest are only read at startup.
+func (c *Client) reloadConfig(newConfig *config.Config) {
+	c.configLock.Lock()
+	defer c.configLock.Unlock()
+	c.baseConfig = newConfig
+	c.config, c.profile = newConfig.ForServer(c.server)
+}
+
+// selectProfile switches to the profile for server once pso is connected to it, refreshing the motd for the
+// account it uses
+func (c *Client) selectProfile(server string) {
+	c.configLock.Lock()
+	if server == c.server {
+		c.configLock.Unlock()
+		return
+	}
+	previousProfile := c.profile
+	c.server = server
+	c.config, c.profile = c.baseConfig.ForServer(server)
+	profile := c.profile
+	c.configLock.Unlock()
+	if profile != previousProfile {
+		log.Printf("Connected to %v, using profile '%v'", server, profile)
+		go func() {
+			c.login(c.getConfig())
+			c.updateMotd()
+		}()
+	}
+}
+
+// openUploadQueues opens the queue in uploadQueueDir for games following the top level autoUpload setting, and a
+// subdirectory for games from profiles that override it
+func openUploadQueues(clientConfig *config.Config) (*upload.Queue, *upload.Queue, error) {
+	autoQueueDir := clientConfig.GetUploadQueueDir()
+	manualQueueDir := filepath.Join(autoQueueDir, "manual")
+	if !clientConfig.AutoUploadEnabled() {
+		manualQueueDir = autoQueueDir
+		autoQueueDir = filepath.Join(manualQueueDir, "auto")
+	}
+	autoQueue, err := upload.Open(autoQueueDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	manualQueue, err := upload.Open(manualQueueDir)
+	return autoQueue, manualQueue, err
+}
+
+// start connects to pso and starts the background work shared by the console ui and headless mode
+func (c *Client) start() {
+	c.login(c.getConfig())
+	c.updateMotd()
+
+	c.pso.StartPersistentConnection(c.errChan)
+	go config.Watch(configFile, time.Second, c.reloadConfig, c.done)
+	if c.liveServer != nil {
+		go func() {
+			if err := c.liveServer.ListenAndServe(c.getConfig().GetLiveServerAddress()); err != nil {
+				log.Printf("Live server stopped %v", err)
+			}
+		}()
+	}
+	if c.liveSplit != nil {
+		go c.liveSplit.Run(c.done)
+	}
+	go c.autoQueue.Run(c.uploadGame, c.done)
+	if c.attempts != nil {
+		go c.attempts.Run(c.done)
+	}
+}
+
+func (c *Client) updateMotd() {
+	if err := c.getMotd(); err != nil {
+		c.ui.Motd = fmt.Sprintf("Error getting message of the day %v", err)
+	}
+	if c.events != nil {
+		log.Printf("Message of the day: %v", c.ui.Motd)
+	}
+}
+
+// stop closes what start opened that isn't already stopped by closing done
+func (c *Client) stop() {
+	if c.liveServer != nil {
+		c.liveServer.Close()
+	}
+}
+
+func (c *Client) questStarted(game pso.QuestRun) {
+	c.ui.BestSegments = nil
+	if c.archive != nil {
+		c.ui.BestSegments = c.archive.BestSegments(game.QuestName, len(game.AllPlayers), game.PbCategory)
+	}
+	if clientConfig := c.getConfig(); clientConfig.GetQuestSplitsEnabled() && clientConfig.GetQuestSplitsCompareTo() != "none" {
+		go func() {
+			err := c.getQuestSplits(game.QuestName, len(game.AllPlayers), game.PbCategory)
+			if err != nil {
+				log.Printf("Error getting quest splits %v", err)
+			}
+		}()
+	}
+}
+
+func (c *Client) questCompleted(game pso.QuestRun) {
+	gameConfig := c.configForServer(game.Server)
+	if c.attempts != nil && gameConfig.UploadAttemptsEnabled() {
+		stats := c.attempts.Stats(game.QuestName, len(game.AllPlayers), game.PbCategory)
+		game.Attempts = stats.Attempts
+		game.Resets = stats.Resets
+	}
+	if c.archive != nil {
+		if _, err := c.archive.Save(game); err != nil {
+			log.Printf("Unable to archive game %v", err)
+		}
+	}
+	uploadQueue := c.autoQueue
+	if !gameConfig.AutoUploadEnabled() {
+		uploadQueue = c.manualQueue
+	}
+	if err := uploadQueue.Push(game); err != nil {
+		log.Printf("Unable to queue game for upload %v", err)
+	} else if !gameConfig.AutoUploadEnabled() {
+		c.pso.GameState.AwaitingUpload = true
+	}
+}
+
+func showConfigErrorUi(clientInfo model.ClientInfo, message string) {
+	cui, err := consoleui.New(clientInfo)
+	if err != nil {
+		log.Printf("failed to initialize termui: %v", err)
+		return
+	}
+	defer cui.Close()
 	width, _ := termui.TerminalDimensions()
 	cui.DrawLogo(width)
 	paragraph := widgets.NewParagraph()
-	paragraph.Text = "Config file missing. Press any key to quit"
-	offset := (width - 44) / 2
-	paragraph.SetRect(offset, 10, offset+48, 13)
+	paragraph.Text = message
+	offset := (width - 80) / 2
+	if offset < 0 {
+		offset = 0
+	}
+	paragraph.SetRect(offset, 10, offset+84, 20)
 	paragraph.Border = false
 	termui.Render(paragraph)
 	uiEvents := termui.PollEvents()
@@ -170,63 +463,133 @@ func (c *Client) writeGameJson() {
 	file.Write(jsonBytes)
 }
 
-func (c *Client) uploadGame(game pso.QuestRun) {
-	if c.pso.GameState.Uploading || c.pso.GameState.UploadSuccessful {
-		// Prevent resubmission
-		return
+// ReplayTrace replays a recorded memory trace, writing each quest run completed in it to a json file next to the trace.
+// Memory offsets are loaded like the client loads them, including offsets.yaml and the configured offsetBuild.
+func ReplayTrace(tracePath string) (int, error) {
+	offsetProfiles, err := offsets.Load("./offsets.yaml")
+	if err != nil {
+		return 0, fmt.Errorf("ReplayTrace: %w", err)
+	}
+	offsetBuild := ""
+	if clientConfig, err := config.ReadFromFile(configFile); err == nil {
+		offsetBuild = clientConfig.GetOffsetBuild()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("ReplayTrace: %w", err)
 	}
+	runs, err := pso.ReplayTrace(tracePath, offsetProfiles, offsetBuild, questDefinitionFiles...)
+	for i, run := range runs {
+		filename := fmt.Sprintf("%v-game-%d.json", strings.TrimSuffix(tracePath, filepath.Ext(tracePath)), i+1)
+		jsonBytes, jsonErr := json.Marshal(run)
+		if jsonErr != nil {
+			return i, jsonErr
+		}
+		if writeErr := os.WriteFile(filename, jsonBytes, 0644); writeErr != nil {
+			return i, writeErr
+		}
+	}
+	return len(runs), err
+}
+
+// uploadAll tries each game waiting for a manual upload once, stopping at the first one the server couldn't take
+func (c *Client) uploadAll() {
+	c.manualQueue.RetryNow()
+	for c.manualQueue.UploadNext(c.uploadGame) {
+	}
+}
+
+// uploadGame posts game to the server, logging the result in headless mode
+func (c *Client) uploadGame(game pso.QuestRun) error {
+	postResponse, err := c.postGame(game)
+	if c.events != nil {
+		gameUrl := ""
+		if err == nil && len(postResponse.Id) > 0 {
+			gameUrl = fmt.Sprintf("%v/%v", c.configForServer(game.Server).GetServerBaseUrl(), postResponse.Id)
+		}
+		c.events.Upload(game, postResponse, gameUrl, err)
+	}
+	return err
+}
+
+// postGame posts game to the server of the profile it was played with, 4xx responses other than timeouts and rate
+// limits are rejections
+func (c *Client) postGame(game pso.QuestRun) (model.PostGameResponse, error) {
+	gameConfig := c.configForServer(game.Server)
+	postResponse := model.PostGameResponse{}
 	game.Client = c.clientInfo
 	c.pso.GameState.Uploading = true
+	defer func() { c.pso.GameState.Uploading = false }()
 	jsonBytes, err := json.Marshal(game)
 	if err != nil {
-		log.Printf("Unable to generate json")
+		return postResponse, &upload.RejectedError{Message: fmt.Sprintf("unable to generate json: %v", err)}
 	}
 	buf := bytes.NewBuffer(jsonBytes)
-	request, err := http.NewRequest("POST", c.config.GetServerBaseUrl()+"/api/game", buf)
+	request, err := http.NewRequest("POST", gameConfig.GetServerBaseUrl()+"/api/game", buf)
 	if err != nil {
-		log.Printf("Failed to build request %v", err)
+		return postResponse, fmt.Errorf("failed to build request: %w", err)
 	}
 	request.Header.Set("Content-Type", "application/json")
-	request.SetBasicAuth(*c.config.User, *c.config.Password)
+	authorize(request, c.credentials, gameConfig)
 	response, err := c.httpClient.Do(request)
 	if err != nil {
-		log.Printf("Unable to upload game %v", err)
+		return postResponse, err
 	}
-	if response == nil || response.StatusCode != 200 {
-		log.Printf("Got response status %v: %v", response.StatusCode, response.Body)
-	} else {
+	defer response.Body.Close()
+	responseBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return postResponse, fmt.Errorf("error reading response from server: %w", err)
+	}
+	switch {
+	case response.StatusCode == http.StatusRequestTimeout || response.StatusCode == http.StatusTooManyRequests:
+		return postResponse, fmt.Errorf("got response status %v", response.StatusCode)
+	case response.StatusCode == http.StatusUnauthorized && len(apiToken(c.credentials, gameConfig)) > 0:
+		// A revoked token shouldn't lose the game, retry once the client logs in again
+		c.forgetRevokedToken(gameConfig)
+		c.login(gameConfig)
+		return postResponse, errors.New("api token rejected")
+	case response.StatusCode >= 400 && response.StatusCode < 500:
+		return postResponse, &upload.RejectedError{StatusCode: response.StatusCode, Message: strings.TrimSpace(string(responseBytes))}
+	case response.StatusCode != 200:
+		return postResponse, fmt.Errorf("got response status %v", response.StatusCode)
+	}
+
+	if game.QuestStartTime.Equal(c.pso.GameState.QuestStartTime) {
 		c.pso.GameState.UploadSuccessful = true
-		responseBytes, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Printf("Error reading response from server %v", err)
-		}
-		postResponse := model.PostGameResponse{}
-		err = json.Unmarshal(responseBytes, &postResponse)
-		if err != nil {
-			log.Printf("Error reading response from server %v", err)
-		} else {
-			gameUrl := fmt.Sprintf("Last game: %v/%v", c.config.GetServerBaseUrl(), postResponse.Id)
-			if postResponse.Record {
-				gameUrl = fmt.Sprintf("%v - RECORD", gameUrl)
-			} else if postResponse.Pb {
-				gameUrl = fmt.Sprintf("%v - PB", gameUrl)
-			}
-			c.ui.Motd = gameUrl
-		}
 	}
+	if err := json.Unmarshal(responseBytes, &postResponse); err != nil {
+		// The server has the game, retrying would upload it twice
+		log.Printf("Error reading response from server %v", err)
+		return postResponse, nil
+	}
+	gameUrl := fmt.Sprintf("Last game: %v/%v", gameConfig.GetServerBaseUrl(), postResponse.Id)
+	if postResponse.Record {
+		gameUrl = fmt.Sprintf("%v - RECORD", gameUrl)
+	} else if postResponse.Pb {
+		gameUrl = fmt.Sprintf("%v - PB", gameUrl)
+	}
+	c.ui.Motd = gameUrl
+	return postResponse, nil
 }
 
 func (c *Client) runUI() {
 	c.ui.ClearScreen()
 	for {
 		select {
-		case <-time.After(c.uiRefreshRate):
+		case <-time.After(c.getConfig().GetUiRefreshRate()):
 			connected, statusString := c.pso.CheckConnection()
 			c.ui.SetConnectionStatus(connected, statusString)
+			if connected {
+				c.selectProfile(c.pso.Server())
+			}
 
 			currentQuest := c.pso.CurrentQuest
 			floorName := c.pso.GetFloorName()
-			err := c.ui.DrawScreen(&c.pso.CurrentPlayerData, &c.pso.GameState, &currentQuest, c.config, floorName)
+			var err error
+			if c.showArchive {
+				err = c.ui.DrawArchive(c.archive.Runs(), c.archiveRow)
+			} else {
+				currentInventory := c.pso.Inventory
+				err = c.ui.DrawScreen(&c.pso.CurrentPlayerData, &c.pso.GameState, &currentQuest, &currentInventory, c.pso.CurrentMonsters, c.getConfig(), floorName)
+			}
 			if err != nil {
 				c.errChan <- fmt.Errorf("runUI: error drawing screen in ui: %w", err)
 				return
@@ -238,22 +601,43 @@ func (c *Client) runUI() {
 	}
 }
 
+// liveSnapshot is read by overlays while the quest is running, like the console ui it reads pso without locking
+func (c *Client) liveSnapshot() live.Snapshot {
+	currentQuest := c.pso.CurrentQuest
+	snapshot := live.Snapshot{
+		GameState: c.pso.GameState,
+		Player:    c.pso.CurrentPlayerData,
+		Splits:    currentQuest.Splits,
+		CompareTo: c.ui.QuestSplits,
+	}
+	if c.pso.GameState.QuestStarted {
+		snapshot.DeathCount = currentQuest.DeathCount
+		snapshot.MonstersKilled = currentQuest.MonstersDead
+		if dataFrame, ok := currentQuest.LatestDataFrame(); ok {
+			snapshot.DataFrame = &dataFrame
+		}
+	}
+	return snapshot
+}
+
 func (c *Client) getMotd() error {
+	clientConfig := c.getConfig()
 	jsonBytes, err := json.Marshal(c.clientInfo)
 	if err != nil {
 		return err
 	}
 	buf := bytes.NewBuffer(jsonBytes)
-	request, err := http.NewRequest("POST", c.config.GetServerBaseUrl()+"/api/motd", buf)
+	request, err := http.NewRequest("POST", clientConfig.GetServerBaseUrl()+"/api/motd", buf)
 	if err != nil {
 		return err
 	}
 	request.Header.Set("Content-Type", "application/json")
-	request.SetBasicAuth(*c.config.User, *c.config.Password)
+	authorize(request, c.credentials, clientConfig)
 	response, err := c.httpClient.Do(request)
 	if err != nil {
 		return err
 	}
+	defer response.Body.Close()
 	responseBytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return err
@@ -264,6 +648,7 @@ func (c *Client) getMotd() error {
 	}
 
 	if !motd.Authorized {
+		c.forgetRevokedToken(clientConfig)
 		c.ui.Motd = "Invalid credentials"
 	} else {
 		c.ui.Motd = motd.Message
@@ -273,21 +658,24 @@ func (c *Client) getMotd() error {
 
 func (c *Client) getQuestSplits(questName string, players int, pbCategory bool) error {
 	c.ui.QuestSplits = nil
-	compareTo := c.config.GetQuestSplitsCompareTo()
+	compareTo := c.getConfig().GetQuestSplitsCompareTo()
 	urlEncodedQuestName := url.PathEscape(questName)
 	var path string
 	if compareTo == "pb" {
-		path = fmt.Sprintf("%v/api/pb-splits/%v?players=%d&pb=%v", c.config.GetServerBaseUrl(), urlEncodedQuestName, players, pbCategory)
+		path = fmt.Sprintf("%v/api/pb-splits/%v?players=%d&pb=%v", c.getConfig().GetServerBaseUrl(), urlEncodedQuestName, players, pbCategory)
 	} else {
-		path = fmt.Sprintf("%v/api/record-splits/%v?players=%d&pb=%v", c.config.GetServerBaseUrl(), urlEncodedQuestName, players, pbCategory)
+		path = fmt.Sprintf("%v/api/record-splits/%v?players=%d&pb=%v", c.getConfig().GetServerBaseUrl(), urlEncodedQuestName, players, pbCategory)
 	}
 	request, err := http.NewRequest("GET", path, nil)
 	if err != nil {
 		return err
 	}
-	request.SetBasicAuth(*c.config.User, *c.config.Password)
+	authorize(request, c.credentials, c.getConfig())
 	response, err := c.httpClient.Do(request)
 	if err != nil {
+		if compareTo == "pb" {
+			c.ui.QuestSplits = c.localPbSplits(questName, players, pbCategory)
+		}
 		return err
 	}
 	if response.StatusCode == 200 {
@@ -304,3 +692,15 @@ func (c *Client) getQuestSplits(questName string, players int, pbCategory bool)
 	}
 	return nil
 }
+
+// localPbSplits compares against archived runs when the server can't be reached, there's no local record to fall back on
+func (c *Client) localPbSplits(questName string, players int, pbCategory bool) []model.QuestRunSplit {
+	if c.archive == nil {
+		return nil
+	}
+	pb, found := c.archive.Pb(questName, players, pbCategory)
+	if !found {
+		return nil
+	}
+	return pb.Splits
+}
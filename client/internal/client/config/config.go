@@ -19,9 +19,23 @@ type Config struct {
 	UiFps                *int    `yaml:"uiFps"`
 	User                 *string `yaml:"user"`
 	Password             *string `yaml:"password"`
+	Token                *string `yaml:"token"`
 	AutoUpload           *bool   `yaml:"autoUpload"`
 	QuestSplitsEnabled   *bool   `yaml:"questSplitsEnabled"`
 	QuestSplitsCompareTo *string `yaml:"questSplitsCompareTo"`
+	RecordTrace          *bool   `yaml:"recordTrace"`
+	OffsetBuild          *string `yaml:"offsetBuild"`
+	ArchiveDir           *string `yaml:"archiveDir"`
+	UploadQueueDir       *string `yaml:"uploadQueueDir"`
+	LiveServerAddress    *string `yaml:"liveServerAddress"`
+	LiveServerOrigins    *string `yaml:"liveServerOrigins"`
+	LiveSplitAddress     *string `yaml:"liveSplitAddress"`
+	UploadAttempts       *bool   `yaml:"uploadAttempts"`
+	DataFrameRate        *int    `yaml:"dataFrameRate"`
+	Headless             *bool   `yaml:"headless"`
+	EventLog             *string `yaml:"eventLog"`
+	// Account and upload settings by pso server, see ForServer
+	Profiles map[string]Profile `yaml:"profiles"`
 }
 
 func (config *Config) GetUiRefreshRate() time.Duration {
@@ -44,6 +58,8 @@ func (config *Config) GetServerBaseUrl() string {
 	}
 }
 
+// ReadFromFile reads the config at fileLocation, applies environment overrides and validates it.
+// Unknown keys are rejected so typos don't silently fall back to defaults.
 func ReadFromFile(fileLocation string) (*Config, error) {
 	config := Config{}
 	data, err := os.ReadFile(fileLocation)
@@ -51,10 +67,16 @@ func ReadFromFile(fileLocation string) (*Config, error) {
 		return nil, err
 	}
 
-	err = yaml.Unmarshal(data, &config)
+	err = yaml.UnmarshalStrict(data, &config)
 	if err != nil {
 		return nil, err
 	}
+	if err = config.applyEnvironment(); err != nil {
+		return nil, err
+	}
+	if err = config.Validate(); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
 
@@ -73,3 +95,108 @@ func (config *Config) GetQuestSplitsCompareTo() string {
 	}
 	return strings.ToLower(compareTo)
 }
+
+func (config *Config) RecordTraceEnabled() bool {
+	return config.RecordTrace != nil && *config.RecordTrace
+}
+
+func (config *Config) GetOffsetBuild() string {
+	if config.OffsetBuild != nil {
+		return *config.OffsetBuild
+	}
+	return ""
+}
+
+func (config *Config) GetArchiveDir() string {
+	if config.ArchiveDir != nil && len(*config.ArchiveDir) > 0 {
+		return *config.ArchiveDir
+	}
+	return "./runs"
+}
+
+func (config *Config) GetUploadQueueDir() string {
+	if config.UploadQueueDir != nil && len(*config.UploadQueueDir) > 0 {
+		return *config.UploadQueueDir
+	}
+	return "./upload-queue"
+}
+
+// GetLiveServerAddress is where overlays can read live game data, empty when the live server is disabled
+func (config *Config) GetLiveServerAddress() string {
+	if config.LiveServerAddress != nil {
+		return *config.LiveServerAddress
+	}
+	return ""
+}
+
+// GetLiveServerOrigins are the pages other than localhost that may read from the live server, the setting is a comma
+// separated list
+func (config *Config) GetLiveServerOrigins() []string {
+	origins := make([]string, 0)
+	if config.LiveServerOrigins == nil {
+		return origins
+	}
+	for _, origin := range strings.Split(*config.LiveServerOrigins, ",") {
+		if origin = strings.TrimSpace(origin); len(origin) > 0 {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// GetLiveSplitAddress is the LiveSplit Server to drive from quest transitions, empty when disabled
+func (config *Config) GetLiveSplitAddress() string {
+	if config.LiveSplitAddress != nil {
+		return *config.LiveSplitAddress
+	}
+	return ""
+}
+
+func (config *Config) UploadAttemptsEnabled() bool {
+	return config.UploadAttempts != nil && *config.UploadAttempts
+}
+
+// GetDataFrameRate is how many delta encoded data frames to record a second, zero keeps one full frame a second
+func (config *Config) GetDataFrameRate() int {
+	if config.DataFrameRate == nil {
+		return 0
+	}
+	if *config.DataFrameRate < 0 || *config.DataFrameRate > 30 {
+		log.Printf("dataFrameRate must be between 0 and 30 but was %v, falling back to default(0)", *config.DataFrameRate)
+		return 0
+	}
+	return *config.DataFrameRate
+}
+
+// HeadlessEnabled runs the client without the console ui, same as the -headless flag
+func (config *Config) HeadlessEnabled() bool {
+	return config.Headless != nil && *config.Headless
+}
+
+// GetEventLog is the file headless mode appends events to, empty for stdout
+func (config *Config) GetEventLog() string {
+	if config.EventLog != nil {
+		return *config.EventLog
+	}
+	return ""
+}
+
+// GetToken is an api token set in the config or environment, used ahead of any token the client was issued
+func (config *Config) GetToken() string {
+	if config.Token != nil {
+		return *config.Token
+	}
+	return ""
+}
+
+// GetCredentials is the user and password to authenticate with, empty when they aren't set
+func (config *Config) GetCredentials() (string, string) {
+	user, password := "", ""
+	if config.User != nil {
+		user = *config.User
+	}
+	if config.Password != nil {
+		password = *config.Password
+	}
+	return user, password
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir string, contents string) string {
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFromFile_RejectsUnknownSettings(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "uiFps: 10\nquestSplitsCompareTO: pb\n")
+	_, err := ReadFromFile(path)
+	if err == nil || !strings.Contains(err.Error(), "questSplitsCompareTO") {
+		t.Errorf("Expected the misspelled setting to be rejected but got %v", err)
+	}
+}
+
+func TestReadFromFile_ListsEveryInvalidSetting(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "uiFps: 0\ndataFrameRate: 60\nquestSplitsCompareTo: best\nserverBaseUrl: psostats.com\n"+
+		"liveServerOrigins: 'https://overlays.example.com, overlays.example.com'\n")
+	_, err := ReadFromFile(path)
+	validationError := &ValidationError{}
+	if !errors.As(err, &validationError) {
+		t.Fatalf("Expected a validation error but got %v", err)
+	}
+	if len(validationError.Problems) != 5 {
+		t.Errorf("Expected 5 problems but got %v", validationError.Problems)
+	}
+}
+
+func TestReadFromFile_EnvironmentOverridesFile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "uiFps: 10\nuser: fromFile\n")
+	t.Setenv("PSOSTATS_UI_FPS", "20")
+	t.Setenv("PSOSTATS_USER", "fromEnv")
+	t.Setenv("PSOSTATS_AUTO_UPLOAD", "false")
+	config, err := ReadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GetUiRefreshRate() != time.Second/20 {
+		t.Errorf("Expected uiFps from the environment but refresh rate was %v", config.GetUiRefreshRate())
+	}
+	if user, _ := config.GetCredentials(); user != "fromEnv" {
+		t.Errorf("Expected user from the environment but was %v", user)
+	}
+	if config.AutoUploadEnabled() {
+		t.Errorf("Expected autoUpload to be turned off by the environment")
+	}
+
+	t.Setenv("PSOSTATS_UI_FPS", "fast")
+	if _, err := ReadFromFile(path); err == nil {
+		t.Errorf("Expected a non numeric uiFps to be rejected")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if name := envName("serverBaseUrl"); name != "PSOSTATS_SERVER_BASE_URL" {
+		t.Errorf("Expected PSOSTATS_SERVER_BASE_URL but got %v", name)
+	}
+}
+
+// syncBuffer collects log output written by the watcher while the test reads it
+type syncBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.String()
+}
+
+func TestWatch_KeepsPreviousConfigOnInvalidChange(t *testing.T) {
+	logs := &syncBuffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "uiFps: 10\n")
+	changes := make(chan *Config, 10)
+	done := make(chan struct{})
+	defer close(done)
+	go Watch(path, 10*time.Millisecond, func(config *Config) { changes <- config }, done)
+	// Give the watcher time to read the starting modified time
+	time.Sleep(50 * time.Millisecond)
+
+	// Bump the modified time so coarse filesystem timestamps still register each change
+	rewrite := func(contents string, modified time.Time) {
+		writeConfig(t, dir, contents)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rewrite("uiFps: 99\n", time.Now().Add(time.Minute))
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(logs.String(), "Rejected change"); {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the invalid change to be rejected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case config := <-changes:
+		t.Fatalf("Expected the invalid change to be skipped but got refresh rate %v", config.GetUiRefreshRate())
+	default:
+	}
+
+	rewrite("uiFps: 20\n", time.Now().Add(2*time.Minute))
+	select {
+	case config := <-changes:
+		if config.GetUiRefreshRate() != time.Second/20 {
+			t.Errorf("Expected the valid change but got refresh rate %v", config.GetUiRefreshRate())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the config change")
+	}
+}
+
+func TestForServer_AppliesMatchingProfile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), `user: main
+password: secret
+autoUpload: true
+profiles:
+  ephinea:
+    serverBaseUrl: http://localhost:8080
+    user: practice
+  unseenAlt:
+    server: unseen
+    autoUpload: false
+`)
+	config, err := ReadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ephinea, profile := config.ForServer("ephinea")
+	user, password := ephinea.GetCredentials()
+	if profile != "ephinea" || user != "practice" || password != "secret" || ephinea.GetServerBaseUrl() != "http://localhost:8080" {
+		t.Errorf("Expected the ephinea profile over the top level settings but got profile '%v' %v/%v at %v",
+			profile, user, password, ephinea.GetServerBaseUrl())
+	}
+	unseen, profile := config.ForServer("unseen")
+	if profile != "unseenAlt" || unseen.AutoUploadEnabled() {
+		t.Errorf("Expected the unseenAlt profile to turn off autoUpload but got profile '%v'", profile)
+	}
+	if user, _ := unseen.GetCredentials(); user != "main" {
+		t.Errorf("Expected the top level user where the profile doesn't set one but got %v", user)
+	}
+	if unmatched, profile := config.ForServer(""); unmatched != config || len(profile) > 0 {
+		t.Errorf("Expected the top level config before connecting but got profile '%v'", profile)
+	}
+	if !config.AutoUploadEnabled() {
+		t.Errorf("Applying a profile shouldn't change the top level config")
+	}
+}
+
+func TestReadFromFile_RejectsConflictingProfiles(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), `profiles:
+  first:
+    server: ephinea
+  second:
+    server: ephinea
+  typo:
+    server: ephinia
+`)
+	_, err := ReadFromFile(path)
+	validationError := &ValidationError{}
+	if !errors.As(err, &validationError) {
+		t.Fatalf("Expected a validation error but got %v", err)
+	}
+	if len(validationError.Problems) != 2 {
+		t.Errorf("Expected the duplicate and unknown server to be reported but got %v", validationError.Problems)
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso/constants"
+)
+
+// Profile overrides the account and upload settings while connected to one pso server, unset fields keep the
+// top level setting
+type Profile struct {
+	// The pso server the profile is used for, ephinea or unseen. Defaults to the profile's name
+	Server         *string `yaml:"server"`
+	ServerBaseUrl  *string `yaml:"serverBaseUrl"`
+	User           *string `yaml:"user"`
+	Password       *string `yaml:"password"`
+	Token          *string `yaml:"token"`
+	AutoUpload     *bool   `yaml:"autoUpload"`
+	UploadAttempts *bool   `yaml:"uploadAttempts"`
+}
+
+func (profile Profile) server(name string) string {
+	if profile.Server != nil {
+		return *profile.Server
+	}
+	return name
+}
+
+// ForServer is config with the profile for the pso server applied, and the profile's name. Config is returned as is,
+// with an empty name, when no profile is for server.
+func (config *Config) ForServer(server string) (*Config, string) {
+	for name, profile := range config.Profiles {
+		if profile.server(name) != server {
+			continue
+		}
+		merged := *config
+		if profile.ServerBaseUrl != nil {
+			merged.ServerBaseUrl = profile.ServerBaseUrl
+		}
+		if profile.User != nil {
+			merged.User = profile.User
+		}
+		if profile.Password != nil {
+			merged.Password = profile.Password
+		}
+		if profile.Token != nil {
+			merged.Token = profile.Token
+		}
+		if profile.AutoUpload != nil {
+			merged.AutoUpload = profile.AutoUpload
+		}
+		if profile.UploadAttempts != nil {
+			merged.UploadAttempts = profile.UploadAttempts
+		}
+		return &merged, name
+	}
+	return config, ""
+}
+
+// validateProfiles checks each profile is for a known server and no two are for the same one
+func (config *Config) validateProfiles() []string {
+	problems := make([]string, 0)
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	profileByServer := make(map[string]string)
+	for _, name := range names {
+		profile := config.Profiles[name]
+		server := profile.server(name)
+		if server != constants.EphineaServerName && server != constants.UnseenServerName {
+			problems = append(problems, fmt.Sprintf("profile %v must be for server %v or %v but was for '%v'",
+				name, constants.EphineaServerName, constants.UnseenServerName, server))
+		} else if other, found := profileByServer[server]; found {
+			problems = append(problems, fmt.Sprintf("profiles %v and %v are both for server %v", other, name, server))
+		}
+		profileByServer[server] = name
+		if profile.ServerBaseUrl != nil {
+			if problem := validateServerBaseUrl(*profile.ServerBaseUrl); len(problem) > 0 {
+				problems = append(problems, fmt.Sprintf("profile %v %v", name, problem))
+			}
+		}
+	}
+	return problems
+}
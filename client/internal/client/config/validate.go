@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Every top level setting other than profiles can be overridden by an environment variable named after its yaml key,
+// serverBaseUrl is PSOSTATS_SERVER_BASE_URL
+const envPrefix = "PSOSTATS_"
+
+// ValidationError lists everything wrong with a config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks settings that would otherwise fail later on, returning a *ValidationError listing all of them
+func (config *Config) Validate() error {
+	problems := make([]string, 0)
+	if config.ServerBaseUrl != nil {
+		if problem := validateServerBaseUrl(*config.ServerBaseUrl); len(problem) > 0 {
+			problems = append(problems, problem)
+		}
+	}
+	for _, origin := range config.GetLiveServerOrigins() {
+		if problem := validateOrigin(origin); len(problem) > 0 {
+			problems = append(problems, problem)
+		}
+	}
+	if config.UiFps != nil && (*config.UiFps <= 0 || *config.UiFps > 30) {
+		problems = append(problems, fmt.Sprintf("uiFps must be between 1 and 30 but was %v", *config.UiFps))
+	}
+	if config.DataFrameRate != nil && (*config.DataFrameRate < 0 || *config.DataFrameRate > 30) {
+		problems = append(problems, fmt.Sprintf("dataFrameRate must be between 0 and 30 but was %v", *config.DataFrameRate))
+	}
+	if config.QuestSplitsCompareTo != nil {
+		switch config.GetQuestSplitsCompareTo() {
+		case "pb", "record", "none":
+		default:
+			problems = append(problems, fmt.Sprintf("questSplitsCompareTo must be pb, record or none but was '%v'", *config.QuestSplitsCompareTo))
+		}
+	}
+	problems = append(problems, config.validateProfiles()...)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateServerBaseUrl(serverBaseUrl string) string {
+	serverUrl, err := url.Parse(serverBaseUrl)
+	if err != nil || (serverUrl.Scheme != "http" && serverUrl.Scheme != "https") || len(serverUrl.Host) == 0 {
+		return fmt.Sprintf("serverBaseUrl must be an http or https url but was '%v'", serverBaseUrl)
+	}
+	return ""
+}
+
+func validateOrigin(origin string) string {
+	originUrl, err := url.Parse(origin)
+	if err != nil || (originUrl.Scheme != "http" && originUrl.Scheme != "https") || len(originUrl.Host) == 0 {
+		return fmt.Sprintf("liveServerOrigins must be http or https origins like https://example.com but had '%v'", origin)
+	}
+	return ""
+}
+
+// applyEnvironment overrides each setting that has an environment variable set
+func (config *Config) applyEnvironment() error {
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("yaml")
+		envValue, found := os.LookupEnv(envName(key))
+		if !found {
+			continue
+		}
+		if field.Type.Kind() != reflect.Ptr {
+			return fmt.Errorf("%v can't be set from the environment", envName(key))
+		}
+		setting := reflect.New(field.Type.Elem())
+		switch field.Type.Elem().Kind() {
+		case reflect.String:
+			setting.Elem().SetString(envValue)
+		case reflect.Int:
+			parsed, err := strconv.Atoi(envValue)
+			if err != nil {
+				return fmt.Errorf("%v must be a number but was '%v'", envName(key), envValue)
+			}
+			setting.Elem().SetInt(int64(parsed))
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(envValue)
+			if err != nil {
+				return fmt.Errorf("%v must be true or false but was '%v'", envName(key), envValue)
+			}
+			setting.Elem().SetBool(parsed)
+		default:
+			return fmt.Errorf("%v can't be set from the environment", envName(key))
+		}
+		value.Field(i).Set(setting)
+	}
+	return nil
+}
+
+// envName turns a yaml key like serverBaseUrl into PSOSTATS_SERVER_BASE_URL
+func envName(key string) string {
+	name := strings.Builder{}
+	name.WriteString(envPrefix)
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			name.WriteRune('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Watch checks the file at fileLocation every interval and calls onChange with the new config each time it changes.
+// A change is only read once the file has been left alone for an interval, so half written files aren't picked up.
+// Changes that don't read or validate are logged and skipped, leaving the previous config in place.
+// It returns once done is closed.
+func Watch(fileLocation string, interval time.Duration, onChange func(*Config), done <-chan struct{}) {
+	lastModified := modifiedAt(fileLocation)
+	pending := lastModified
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			modified := modifiedAt(fileLocation)
+			if modified.Equal(lastModified) {
+				continue
+			}
+			if !modified.Equal(pending) {
+				pending = modified
+				continue
+			}
+			lastModified = modified
+			config, err := ReadFromFile(fileLocation)
+			if err != nil {
+				log.Printf("Rejected change to %v, keeping the previous config: %v", fileLocation, err)
+				continue
+			}
+			log.Printf("Reloaded %v", fileLocation)
+			onChange(config)
+		}
+	}
+}
+
+func modifiedAt(fileLocation string) time.Time {
+	info, err := os.Stat(fileLocation)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/phelix-/psostats/v2/client/internal/client/config"
+	"github.com/phelix-/psostats/v2/client/internal/credentials"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// Api tokens the client was issued, once there's one for an account its password isn't sent again
+const credentialsFile = "./credentials.json"
+
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// apiToken is the token to authenticate clientConfig's account with, empty until one is issued
+func apiToken(store *credentials.Store, clientConfig *config.Config) string {
+	if token := clientConfig.GetToken(); len(token) > 0 {
+		return token
+	}
+	if store == nil {
+		return ""
+	}
+	user, _ := clientConfig.GetCredentials()
+	token, _ := store.Token(clientConfig.GetServerBaseUrl(), user)
+	return token
+}
+
+// authorize signs request with the api token for clientConfig's account, falling back to its password
+func authorize(request *http.Request, store *credentials.Store, clientConfig *config.Config) {
+	if token := apiToken(store, clientConfig); len(token) > 0 {
+		request.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	request.SetBasicAuth(clientConfig.GetCredentials())
+}
+
+// requestApiToken exchanges user's password for a new api token named after this machine
+func requestApiToken(httpClient *http.Client, serverBaseUrl string, user string, password string) (string, error) {
+	name := "psostats client"
+	if hostname, err := os.Hostname(); err == nil {
+		name = fmt.Sprintf("psostats client on %v", hostname)
+	}
+	jsonBytes, err := json.Marshal(model.CreateApiTokenRequest{Name: name})
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequest("POST", serverBaseUrl+"/api/tokens", bytes.NewBuffer(jsonBytes))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.SetBasicAuth(user, password)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized {
+		return "", errInvalidCredentials
+	} else if response.StatusCode != 200 {
+		return "", fmt.Errorf("got response status %v", response.StatusCode)
+	}
+	responseBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	apiToken := model.ApiToken{}
+	if err := json.Unmarshal(responseBytes, &apiToken); err != nil {
+		return "", err
+	}
+	return apiToken.Token, nil
+}
+
+// login swaps clientConfig's password for an api token the first time the account is used
+func (c *Client) login(clientConfig *config.Config) {
+	user, password := clientConfig.GetCredentials()
+	if c.credentials == nil || len(password) == 0 || len(apiToken(c.credentials, clientConfig)) > 0 {
+		return
+	}
+	token, err := requestApiToken(&c.httpClient, clientConfig.GetServerBaseUrl(), user, password)
+	if err != nil {
+		log.Printf("Unable to get an api token for %v, sending the password instead %v", user, err)
+		return
+	}
+	if err := c.credentials.Save(clientConfig.GetServerBaseUrl(), user, token); err != nil {
+		log.Printf("Unable to save api token %v", err)
+		return
+	}
+	log.Printf("Logged in to %v as %v, the password can be removed from config.yaml", clientConfig.GetServerBaseUrl(), user)
+}
+
+// forgetRevokedToken drops the saved token for clientConfig's account after the server stops accepting it,
+// so the next start logs in with the password again if there is one
+func (c *Client) forgetRevokedToken(clientConfig *config.Config) {
+	if c.credentials == nil || len(clientConfig.GetToken()) > 0 {
+		return
+	}
+	user, _ := clientConfig.GetCredentials()
+	if _, found := c.credentials.Token(clientConfig.GetServerBaseUrl(), user); !found {
+		return
+	}
+	log.Printf("Api token for %v was rejected, forgetting it", user)
+	if err := c.credentials.Remove(clientConfig.GetServerBaseUrl(), user); err != nil {
+		log.Printf("Unable to remove api token %v", err)
+	}
+}
+
+func readConfigAndCredentials() (*config.Config, *credentials.Store, error) {
+	clientConfig, err := config.ReadFromFile(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := credentials.Open(credentialsFile)
+	return clientConfig, store, err
+}
+
+// Login exchanges user's password for an api token on the server in config.yaml and saves it,
+// so the password doesn't need to be kept in the config
+func Login(user string, password string) error {
+	clientConfig, store, err := readConfigAndCredentials()
+	if err != nil {
+		return fmt.Errorf("Login: %w", err)
+	}
+	token, err := requestApiToken(&http.Client{}, clientConfig.GetServerBaseUrl(), user, password)
+	if err != nil {
+		return fmt.Errorf("Login: %w", err)
+	}
+	return store.Save(clientConfig.GetServerBaseUrl(), user, token)
+}
+
+// ListApiTokens lists the tokens issued to the account in config.yaml
+func ListApiTokens() ([]model.ApiToken, error) {
+	clientConfig, store, err := readConfigAndCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("ListApiTokens: %w", err)
+	}
+	request, err := http.NewRequest("GET", clientConfig.GetServerBaseUrl()+"/api/tokens", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ListApiTokens: %w", err)
+	}
+	authorize(request, store, clientConfig)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("ListApiTokens: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("ListApiTokens: %w", errInvalidCredentials)
+	} else if response.StatusCode != 200 {
+		return nil, fmt.Errorf("ListApiTokens: got response status %v", response.StatusCode)
+	}
+	responseBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ListApiTokens: %w", err)
+	}
+	tokens := make([]model.ApiToken, 0)
+	if err := json.Unmarshal(responseBytes, &tokens); err != nil {
+		return nil, fmt.Errorf("ListApiTokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeApiToken stops the token with tokenId working, for a lost machine or a leaked token
+func RevokeApiToken(tokenId string) error {
+	clientConfig, store, err := readConfigAndCredentials()
+	if err != nil {
+		return fmt.Errorf("RevokeApiToken: %w", err)
+	}
+	request, err := http.NewRequest("DELETE", clientConfig.GetServerBaseUrl()+"/api/tokens/"+tokenId, nil)
+	if err != nil {
+		return fmt.Errorf("RevokeApiToken: %w", err)
+	}
+	authorize(request, store, clientConfig)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("RevokeApiToken: %w", err)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case 200:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("RevokeApiToken: %w", errInvalidCredentials)
+	case http.StatusNotFound:
+		return fmt.Errorf("RevokeApiToken: no token %v", tokenId)
+	default:
+		return fmt.Errorf("RevokeApiToken: got response status %v", response.StatusCode)
+	}
+}
@@ -0,0 +1,83 @@
+package consoleui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+)
+
+// segmentEnded is true once a split has a final time, the last split ends with the quest
+func segmentEnded(index int, quest *pso.QuestRun) bool {
+	split := quest.Splits[index]
+	if split.Start.IsZero() {
+		return false
+	}
+	return !split.End.IsZero() || quest.QuestComplete
+}
+
+// isGoldSplit is true when a finished split beat the best archived time for it
+func (cui *ConsoleUI) isGoldSplit(index int, quest *pso.QuestRun) bool {
+	if index >= len(cui.BestSegments) || cui.BestSegments[index] == 0 || !segmentEnded(index, quest) {
+		return false
+	}
+	return splitDuration(quest.Splits[index], quest) < cui.BestSegments[index]
+}
+
+// sumOfBest adds up the best time of every split, false until each split has been finished at least once
+func (cui *ConsoleUI) sumOfBest(splitCount int) (time.Duration, bool) {
+	sum := time.Duration(0)
+	for i := 0; i < splitCount; i++ {
+		if i >= len(cui.BestSegments) || cui.BestSegments[i] == 0 {
+			return 0, false
+		}
+		sum += cui.BestSegments[i]
+	}
+	return sum, true
+}
+
+// bestPossibleTime is the quest time if every split from here on matches its best
+func (cui *ConsoleUI) bestPossibleTime(quest *pso.QuestRun) (time.Duration, bool) {
+	total := time.Duration(0)
+	for i, split := range quest.Splits {
+		var best time.Duration
+		if i < len(cui.BestSegments) {
+			best = cui.BestSegments[i]
+		}
+		switch {
+		case segmentEnded(i, quest):
+			total += splitDuration(split, quest)
+		case best == 0:
+			return 0, false
+		case !split.Start.IsZero():
+			// The current split can't finish faster than it's already taken
+			if elapsed := splitDuration(split, quest); elapsed > best {
+				best = elapsed
+			}
+			total += best
+		default:
+			total += best
+		}
+	}
+	return total, true
+}
+
+func (cui *ConsoleUI) formatSumOfBest(quest *pso.QuestRun) []string {
+	rows := make([]string, 0, 2)
+	if sumOfBest, ok := cui.sumOfBest(len(quest.Splits)); ok {
+		rows = append(rows, formatBestRow("Sum of Best", sumOfBest))
+	}
+	if bestPossible, ok := cui.bestPossibleTime(quest); ok {
+		rows = append(rows, formatBestRow("Best Possible", bestPossible))
+	}
+	return rows
+}
+
+func formatBestRow(name string, duration time.Duration) string {
+	return fmt.Sprintf("%12v: %v", name, duration.Truncate(time.Millisecond*100))
+}
+
+// gold marks a row in termui's style markup
+func gold(row string) string {
+	return "[" + row + "](fg:yellow)"
+}
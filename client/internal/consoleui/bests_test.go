@@ -0,0 +1,68 @@
+package consoleui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// threeSplitQuest is in its third split 30s in, having taken 50s and 100s for the first two
+func threeSplitQuest() *pso.QuestRun {
+	start := time.Now().Add(-3 * time.Minute)
+	return &pso.QuestRun{
+		QuestStartTime: start,
+		Splits: []model.QuestRunSplit{
+			{Name: "Forest", Start: start, End: start.Add(50 * time.Second)},
+			{Name: "Caves", Start: start.Add(50 * time.Second), End: start.Add(150 * time.Second)},
+			{Name: "Mines", Start: start.Add(150 * time.Second)},
+		},
+	}
+}
+
+func TestGoldSplits(t *testing.T) {
+	cui := &ConsoleUI{BestSegments: []time.Duration{time.Minute, time.Minute, time.Minute}}
+	quest := threeSplitQuest()
+	if !cui.isGoldSplit(0, quest) {
+		t.Error("Expected a 50s split to beat a 1m best")
+	}
+	if cui.isGoldSplit(1, quest) {
+		t.Error("Expected a 100s split not to beat a 1m best")
+	}
+	if cui.isGoldSplit(2, quest) {
+		t.Error("Expected the running split not to be gold")
+	}
+	cui.BestSegments = nil
+	if cui.isGoldSplit(0, quest) {
+		t.Error("Expected no gold splits without best segments")
+	}
+}
+
+func TestSumOfBestAndBestPossibleTime(t *testing.T) {
+	cui := &ConsoleUI{BestSegments: []time.Duration{time.Minute, time.Minute, 40 * time.Second}}
+	quest := threeSplitQuest()
+	if sumOfBest, ok := cui.sumOfBest(len(quest.Splits)); !ok || sumOfBest != 160*time.Second {
+		t.Errorf("Expected a sum of best of 2m40s but got %v %v", sumOfBest, ok)
+	}
+	// 50s + 100s finished, then the current split can't beat 40s
+	bestPossible, ok := cui.bestPossibleTime(quest)
+	if !ok || bestPossible != 190*time.Second {
+		t.Errorf("Expected a best possible time of 3m10s but got %v %v", bestPossible, ok)
+	}
+
+	// Already slower than the best for the current split
+	cui.BestSegments[2] = 10 * time.Second
+	bestPossible, _ = cui.bestPossibleTime(quest)
+	if bestPossible < 180*time.Second || bestPossible > 181*time.Second {
+		t.Errorf("Expected a best possible time of about 3m but got %v", bestPossible)
+	}
+
+	cui.BestSegments = cui.BestSegments[:2]
+	if _, ok := cui.sumOfBest(len(quest.Splits)); ok {
+		t.Error("Expected no sum of best while a split has never been finished")
+	}
+	if _, ok := cui.bestPossibleTime(quest); ok {
+		t.Error("Expected no best possible time while a split has never been finished")
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/phelix-/psostats/v2/client/internal/archive"
+	"github.com/phelix-/psostats/v2/client/internal/attempts"
 	"github.com/phelix-/psostats/v2/client/internal/client/config"
+	"github.com/phelix-/psostats/v2/client/internal/upload"
 	"github.com/phelix-/psostats/v2/pkg/model"
 
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
 	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/inventory"
 	"github.com/phelix-/psostats/v2/client/internal/pso/player"
 )
 
@@ -24,10 +28,16 @@ type Data struct {
 }
 
 type ConsoleUI struct {
-	data        Data
-	Motd        string
-	QuestSplits []model.QuestRunSplit
-	termWidth   int
+	data          Data
+	Motd          string
+	QuestSplits   []model.QuestRunSplit
+	BestSegments  []time.Duration
+	QuestWarnings []string
+	UploadQueue   *upload.Queue
+	Attempts      *attempts.Tracker
+	termWidth     int
+	termHeight    int
+	page          Page
 }
 
 func New(clientInfo model.ClientInfo) (*ConsoleUI, error) {
@@ -35,7 +45,12 @@ func New(clientInfo model.ClientInfo) (*ConsoleUI, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize termui: %w", err)
 	}
+	return NewHeadless(clientInfo), nil
+}
 
+// NewHeadless holds the motd, splits and warnings the client keeps for the ui without touching the terminal,
+// nothing may be drawn with it
+func NewHeadless(clientInfo model.ClientInfo) *ConsoleUI {
 	data := Data{
 		Connected:  false,
 		Status:     "Initializing",
@@ -43,11 +58,9 @@ func New(clientInfo model.ClientInfo) (*ConsoleUI, error) {
 	}
 
 	return &ConsoleUI{
-		data,
-		"",
-		nil,
-		0,
-	}, nil
+		data: data,
+		page: PageQuest,
+	}
 }
 
 func (cui *ConsoleUI) Close() {
@@ -57,22 +70,25 @@ func (cui *ConsoleUI) Close() {
 func (cui *ConsoleUI) ClearScreen() {
 	ui.Clear()
 	cui.termWidth = 0
+	cui.termHeight = 0
 }
 
 func (cui *ConsoleUI) DrawScreen(
 	playerData *player.BasePlayerInfo,
 	gameState *pso.GameState,
 	currentQuest *pso.QuestRun,
+	inventory *inventory.Inventory,
+	monsters []pso.Monster,
 	config *config.Config,
 	floorName string,
 ) error {
-	termWidth := cui.termWidth
+	termWidth, termHeight := cui.termWidth, cui.termHeight
 	if termWidth < 1 {
-		termWidth, _ = ui.TerminalDimensions()
+		termWidth, termHeight = ui.TerminalDimensions()
 		if termWidth < 1 {
 			log.Fatal("Unable to read terminal dimensions")
 		}
-		cui.termWidth = termWidth
+		cui.termWidth, cui.termHeight = termWidth, termHeight
 	}
 	cui.DrawLogo(termWidth)
 	cui.drawMotd(termWidth)
@@ -80,12 +96,28 @@ func (cui *ConsoleUI) DrawScreen(
 	cui.drawRecording(gameState, termWidth)
 	cui.DrawHP(playerData, termWidth)
 	cui.DrawLocation(floorName, playerData, gameState, termWidth)
-	showQuestSplits := config.GetQuestSplitsEnabled() && len(currentQuest.Splits) > 0
-	cui.drawQuestInfo(gameState, currentQuest, playerData, termWidth)
-	cui.drawQuestInfo2(gameState, currentQuest, termWidth)
-	if showQuestSplits {
-		cui.drawQuestSplits(gameState, currentQuest, termWidth)
+	cui.drawTabs(termWidth)
+	switch cui.page {
+	case PageSplits:
+		cui.drawSplitsPage(gameState, currentQuest, termWidth, termHeight)
+	case PageParty:
+		cui.drawPartyPage(gameState, currentQuest, termWidth, termHeight)
+	case PageMonsters:
+		cui.drawMonstersPage(monsters, termWidth, termHeight)
+	case PageInventory:
+		cui.drawInventoryPage(inventory, termWidth, termHeight)
+	default:
+		showQuestSplits := config.GetQuestSplitsEnabled() && len(currentQuest.Splits) > 0
+		cui.drawQuestInfo(gameState, currentQuest, playerData, termWidth)
+		cui.drawQuestInfo2(gameState, currentQuest, termWidth)
+		if showQuestSplits {
+			cui.drawQuestSplits(gameState, currentQuest, termWidth)
+		}
+		if !gameState.QuestStarted && len(cui.QuestWarnings) > 0 {
+			cui.drawQuestWarnings(termWidth)
+		}
 	}
+	cui.drawKeyBindings(termWidth, termHeight)
 	return nil
 }
 
@@ -176,6 +208,10 @@ func (cui *ConsoleUI) drawRecording(gameState *pso.GameState, width int) {
 		recording.TextStyle.Fg = ui.ColorRed
 		recording.Text = "[[ Waiting for Quest Start ]] "
 	}
+	if queueStatus := cui.formatUploadQueue(); len(queueStatus) > 0 {
+		recording.Text += queueStatus
+		recording.TextStyle.Fg = ui.ColorYellow
+	}
 	recording.Border = false
 	recording.WrapText = false
 	recording.Text = padToCenter(recording.Text, width+1)
@@ -183,6 +219,31 @@ func (cui *ConsoleUI) drawRecording(gameState *pso.GameState, width int) {
 	ui.Render(recording)
 }
 
+func (cui *ConsoleUI) formatUploadQueue() string {
+	if cui.UploadQueue == nil {
+		return ""
+	}
+	queued := cui.UploadQueue.Len()
+	lastError := cui.UploadQueue.LastError()
+	status := ""
+	if queued > 0 {
+		status = fmt.Sprintf("%v Queued", queued)
+		if untilRetry := time.Until(cui.UploadQueue.NextAttempt()); untilRetry > 0 {
+			status = fmt.Sprintf("%v, Retrying in %v", status, untilRetry.Truncate(time.Second))
+		}
+	}
+	if len(lastError) > 0 {
+		if len(status) > 0 {
+			status += ": "
+		}
+		status += lastError
+	}
+	if len(status) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[[ %v ]] ", status)
+}
+
 func (cui *ConsoleUI) DrawHP(playerData *player.BasePlayerInfo, width int) {
 	playerInfo := widgets.NewParagraph()
 	playerInfo.Text = fmt.Sprintf("%v - %v (gc: %v)", playerData.Class, playerData.Name, playerData.GuildCard)
@@ -239,10 +300,14 @@ func (cui *ConsoleUI) drawQuestInfo(
 			fmt.Sprintf("%40v", formatCategory(quest)),
 			fmt.Sprintf("%40v", formatQuestTime(quest)),
 		}
-		if quest.QuestName == "Endless: Episode 1" || "Endless: Episode 2" {
+		if quest.QuestName == "Endless: Episode 1" || quest.QuestName == "Endless: Episode 2" {
 			list.Rows = append(list.Rows, fmt.Sprintf("%28v:%11v", "Points", quest.Points))
 		}
 		list.Rows = append(list.Rows, fmt.Sprintf("%40v", formatMesetaCharged(quest)))
+		if cui.Attempts != nil {
+			stats := cui.Attempts.Stats(quest.QuestName, len(quest.AllPlayers), quest.PbCategory)
+			list.Rows = append(list.Rows, fmt.Sprintf("%40v", formatAttempts(stats)))
+		}
 		list.Rows = append(list.Rows, fmt.Sprintf("%40v", formatDeaths(quest)))
 		list.Rows = append(list.Rows, fmt.Sprintf("%40v", formatMonstersAlive(quest)))
 		list.Rows = append(list.Rows, fmt.Sprintf("%40v", formatMonstersKilled(quest)))
@@ -295,9 +360,16 @@ func (cui *ConsoleUI) drawQuestSplits(
 				if cui.QuestSplits != nil && len(cui.QuestSplits) > i {
 					compareTo = &cui.QuestSplits[i]
 				}
-				list.Rows = append(list.Rows, formatSplitTime(split, quest, compareTo))
+				row := formatSplitTime(split, quest, compareTo)
+				if cui.isGoldSplit(i, quest) {
+					row = gold(row)
+				}
+				list.Rows = append(list.Rows, row)
 			}
 		}
+		for _, row := range cui.formatSumOfBest(quest) {
+			list.Rows = append(list.Rows, row)
+		}
 	}
 
 	list.WrapText = false
@@ -306,6 +378,71 @@ func (cui *ConsoleUI) drawQuestSplits(
 	ui.Render(list)
 }
 
+func (cui *ConsoleUI) drawQuestWarnings(width int) {
+	list := widgets.NewList()
+	list.Title = "[[ Problems in quest definition files ]]"
+	list.TitleStyle.Fg = ui.ColorYellow
+	list.Rows = cui.QuestWarnings
+	list.TextStyle.Fg = ui.ColorYellow
+	list.WrapText = false
+	list.Border = false
+	list.SetRect(0, 17, width, 18+len(cui.QuestWarnings))
+	ui.Render(list)
+}
+
+// DrawArchive lists archived runs, newest first, next to the splits of the selected run
+func (cui *ConsoleUI) DrawArchive(runs []archive.Entry, selected int) error {
+	termWidth, termHeight := ui.TerminalDimensions()
+	if termWidth < 1 {
+		return fmt.Errorf("DrawArchive: unable to read terminal dimensions")
+	}
+	cui.DrawLogo(termWidth)
+	cui.drawConnection(termWidth)
+
+	list := widgets.NewList()
+	list.Title = fmt.Sprintf("[[ Run Archive: %v runs - up/down to browse, a to go back ]]", len(runs))
+	list.Rows = make([]string, len(runs))
+	for i, run := range runs {
+		list.Rows[i] = formatArchiveEntry(run)
+	}
+	if len(runs) == 0 {
+		list.Rows = []string{"No runs archived yet"}
+	}
+	list.SelectedRow = selected
+	list.SelectedRowStyle = ui.NewStyle(ui.ColorBlack, ui.ColorCyan)
+	list.WrapText = false
+	list.Border = false
+	list.SetRect(0, 11, termWidth*2/3, termHeight)
+	ui.Render(list)
+
+	splits := widgets.NewList()
+	if selected >= 0 && selected < len(runs) {
+		run := runs[selected]
+		splits.Title = fmt.Sprintf("[[ %v ]]", run.Difficulty)
+		splits.Rows = []string{fmt.Sprintf("%12v: %v", "Deaths", run.DeathCount)}
+		for _, split := range run.Splits {
+			splitDuration := time.Duration(0)
+			if !split.End.IsZero() {
+				splitDuration = split.End.Sub(split.Start)
+			}
+			splits.Rows = append(splits.Rows, fmt.Sprintf("%12v: %v", split.Name, splitDuration.Truncate(time.Millisecond*100)))
+		}
+	}
+	splits.WrapText = false
+	splits.Border = false
+	splits.SetRect(termWidth*2/3, 11, termWidth, termHeight)
+	ui.Render(splits)
+	return nil
+}
+
+func formatArchiveEntry(run archive.Entry) string {
+	duration := "Incomplete"
+	if run.Complete {
+		duration = run.Duration.Truncate(time.Millisecond * 100).String()
+	}
+	return fmt.Sprintf("%v  %-36v %-9v %12v", run.Start.Format("2006-01-02 15:04"), run.QuestName, run.Category(), duration)
+}
+
 func formatCategory(quest *pso.QuestRun) string {
 	playerCount := len(quest.AllPlayers)
 	category := fmt.Sprintf("Category:%4vp ", playerCount)
@@ -326,6 +463,10 @@ func formatMesetaCharged(quest *pso.QuestRun) string {
 	return fmt.Sprintf("Meseta Charged:%11v", mesetaCharged)
 }
 
+func formatAttempts(stats attempts.Stats) string {
+	return fmt.Sprintf("Attempts:%5v (%3.0f%% finished)", stats.Attempts, 100*stats.FinishedRatio())
+}
+
 func formatDeaths(quest *pso.QuestRun) string {
 	return fmt.Sprintf("Deaths:%11v", quest.DeathCount)
 }
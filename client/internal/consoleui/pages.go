@@ -0,0 +1,260 @@
+package consoleui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/phelix-/psostats/v2/client/internal/attempts"
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/inventory"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// Page is one of the views shown below the player header, switched with the number keys or tab
+type Page int
+
+const (
+	PageQuest Page = iota
+	PageSplits
+	PageParty
+	PageMonsters
+	PageInventory
+)
+
+var pageNames = []string{"Quest", "Splits", "Party", "Monsters", "Inventory"}
+
+const keyBindings = "1-5/tab: switch page   w: write game log   u: upload   a: run archive   q: quit"
+
+// SetPage switches to page, ignoring pages that don't exist
+func (cui *ConsoleUI) SetPage(page Page) {
+	if page >= 0 && int(page) < len(pageNames) {
+		cui.page = page
+	}
+}
+
+func (cui *ConsoleUI) NextPage() {
+	cui.page = (cui.page + 1) % Page(len(pageNames))
+}
+
+func (cui *ConsoleUI) drawTabs(width int) {
+	tabs := widgets.NewParagraph()
+	plainText := make([]string, len(pageNames))
+	styledText := make([]string, len(pageNames))
+	for i, name := range pageNames {
+		plainText[i] = fmt.Sprintf(" %v %v ", i+1, name)
+		if Page(i) == cui.page {
+			styledText[i] = fmt.Sprintf("[%v](fg:black,bg:cyan)", plainText[i])
+		} else {
+			styledText[i] = plainText[i]
+		}
+	}
+	// Centered on the unstyled text, style markup isn't drawn
+	offset := (width - len(strings.Join(plainText, " "))) / 2
+	if offset < 0 {
+		offset = 0
+	}
+	tabs.Text = strings.Repeat(" ", offset) + strings.Join(styledText, " ")
+	tabs.Border = false
+	tabs.WrapText = false
+	tabs.SetRect(0, 16, width, 17)
+	ui.Render(tabs)
+}
+
+func (cui *ConsoleUI) drawKeyBindings(width int, height int) {
+	if height < 19 {
+		return
+	}
+	bindings := widgets.NewParagraph()
+	bindings.Text = padToCenter(keyBindings, width)
+	bindings.TextStyle.Fg = ui.ColorCyan
+	bindings.Border = false
+	bindings.WrapText = false
+	bindings.SetRect(0, height-1, width, height)
+	ui.Render(bindings)
+}
+
+// drawPageList draws rows full width under the tabs, leaving the last line for key bindings
+func drawPageList(title string, rows []string, width int, height int) {
+	list := widgets.NewList()
+	list.Title = title
+	list.Rows = rows
+	list.WrapText = false
+	list.Border = false
+	offset := (width - 80) / 2
+	if offset < 0 {
+		offset = 0
+	}
+	list.SetRect(offset, 17, width, height-1)
+	ui.Render(list)
+}
+
+func (cui *ConsoleUI) drawSplitsPage(gameState *pso.GameState, quest *pso.QuestRun, width int, height int) {
+	if !gameState.QuestStarted || len(quest.Splits) == 0 {
+		drawPageList("[[ Splits ]]", []string{"No splits for the current quest"}, width, height)
+		return
+	}
+	var stats *attempts.Stats
+	if cui.Attempts != nil {
+		questStats := cui.Attempts.Stats(quest.QuestName, len(quest.AllPlayers), quest.PbCategory)
+		stats = &questStats
+	}
+	rows := []string{fmt.Sprintf("%-20v %10v %10v %10v %10v %7v", "Split", "Time", "Compare", "Delta", "Ended At", "Resets")}
+	for i, split := range quest.Splits {
+		var compareTo *model.QuestRunSplit
+		if len(cui.QuestSplits) > i {
+			compareTo = &cui.QuestSplits[i]
+		}
+		resets := ""
+		if stats != nil {
+			resets = fmt.Sprint(stats.ResetSplits[split.Name])
+		}
+		row := fmt.Sprintf("%-64v %7v", formatSplitRow(split, quest, compareTo), resets)
+		if cui.isGoldSplit(i, quest) {
+			row = gold(row)
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, cui.formatSumOfBest(quest)...)
+	if stats != nil {
+		rows = append(rows, "", fmt.Sprintf("Attempts: %v  Finished: %v  Resets: %v", stats.Attempts, stats.Finished, stats.Resets))
+	}
+	drawPageList(fmt.Sprintf("[[ Splits: %v ]]", gameState.QuestName), rows, width, height)
+}
+
+func formatSplitRow(split model.QuestRunSplit, quest *pso.QuestRun, compareTo *model.QuestRunSplit) string {
+	name := split.Name
+	if split.Start.IsZero() {
+		return fmt.Sprintf("%-20v %10v", name, "-")
+	}
+	splitDuration := splitDuration(split, quest)
+	compare, delta, endedAt := "", "", ""
+	if compareTo != nil {
+		compareDuration := compareTo.End.Sub(compareTo.Start)
+		compare = compareDuration.Truncate(time.Millisecond * 100).String()
+		if !split.End.IsZero() {
+			delta = formatDelta(splitDuration - compareDuration)
+		}
+	}
+	if !split.End.IsZero() {
+		endedAt = split.End.Sub(quest.QuestStartTime).Truncate(time.Millisecond * 100).String()
+	}
+	return fmt.Sprintf("%-20v %10v %10v %10v %10v", name, splitDuration.Truncate(time.Millisecond*100), compare, delta, endedAt)
+}
+
+func splitDuration(split model.QuestRunSplit, quest *pso.QuestRun) time.Duration {
+	if !split.End.IsZero() {
+		return split.End.Sub(split.Start)
+	} else if quest.QuestComplete {
+		return quest.QuestEndTime.Sub(split.Start)
+	}
+	return time.Now().Sub(split.Start)
+}
+
+func formatDelta(delta time.Duration) string {
+	delta = delta.Truncate(time.Millisecond * 100)
+	if delta > 0 {
+		return "+" + delta.String()
+	}
+	return delta.String()
+}
+
+func (cui *ConsoleUI) drawPartyPage(gameState *pso.GameState, quest *pso.QuestRun, width int, height int) {
+	if !gameState.QuestStarted {
+		drawPageList("[[ Party ]]", []string{"Party damage is tracked once a quest starts"}, width, height)
+		return
+	}
+	drawPageList("[[ Party ]]", formatPartyRows(quest), width, height)
+}
+
+// formatPartyRows lists each player's damage and last hits, both are keyed by party slot
+func formatPartyRows(quest *pso.QuestRun) []string {
+	totalDamage := int64(0)
+	for _, player := range quest.AllPlayers {
+		totalDamage += quest.PlayerDamage[uint16(player.Slot)]
+	}
+	rows := []string{fmt.Sprintf("%-16v %-10v %10v %7v %10v", "Player", "Class", "Damage", "Share", "Last Hits")}
+	for _, player := range quest.AllPlayers {
+		slot := uint16(player.Slot)
+		damage := quest.PlayerDamage[slot]
+		share := 0.0
+		if totalDamage > 0 {
+			share = 100 * float64(damage) / float64(totalDamage)
+		}
+		rows = append(rows, fmt.Sprintf("%-16v %-10v %10v %6.1f%% %10v", player.Name, player.Class, damage, share, quest.LastHits[slot]))
+	}
+	return rows
+}
+
+func (cui *ConsoleUI) drawMonstersPage(monsters []pso.Monster, width int, height int) {
+	rows := formatMonsterRows(monsters)
+	drawPageList(fmt.Sprintf("[[ Monsters: %v alive ]]", len(rows)-1), rows, width, height)
+}
+
+// formatMonsterRows lists living monsters under a header row
+func formatMonsterRows(monsters []pso.Monster) []string {
+	rows := []string{fmt.Sprintf("%-24v %6v  %v", "Monster", "HP", "Status")}
+	for _, monster := range monsters {
+		if monster.Location.HP == 0 {
+			continue
+		}
+		statuses := make([]string, 0)
+		if monster.Location.Frozen {
+			statuses = append(statuses, "Frozen")
+		}
+		if monster.Location.Confused {
+			statuses = append(statuses, "Confused")
+		}
+		if monster.Location.Paralyzed {
+			statuses = append(statuses, "Paralyzed")
+		}
+		rows = append(rows, fmt.Sprintf("%-24v %6v  %v", monster.Name, monster.Location.HP, strings.Join(statuses, ", ")))
+	}
+	return rows
+}
+
+func (cui *ConsoleUI) drawInventoryPage(inventory *inventory.Inventory, width int, height int) {
+	equipment := widgets.NewList()
+	equipment.Title = "[[ Equipped ]]"
+	equipment.Rows = formatEquipmentRows(inventory)
+	equipment.WrapText = false
+	equipment.Border = false
+	equipment.SetRect(0, 17, width*2/3, height-1)
+	ui.Render(equipment)
+
+	consumables := widgets.NewList()
+	consumables.Title = "[[ Consumables ]]"
+	consumables.Rows = formatConsumableRows(inventory)
+	consumables.WrapText = false
+	consumables.Border = false
+	consumables.SetRect(width*2/3, 17, width, height-1)
+	ui.Render(consumables)
+}
+
+func formatEquipmentRows(inventory *inventory.Inventory) []string {
+	rows := make([]string, 0, len(inventory.Equipment))
+	for _, equipment := range inventory.Equipment {
+		rows = append(rows, fmt.Sprintf("%8v: %v", equipment.Type, equipment.Display))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, fmt.Sprintf("%8v: %v", model.EquipmentTypeWeapon, inventory.EquippedWeapon.Display))
+	}
+	return rows
+}
+
+func formatConsumableRows(inventory *inventory.Inventory) []string {
+	return []string{
+		fmt.Sprintf("%13v: %2v", "Monomate", inventory.Monomate),
+		fmt.Sprintf("%13v: %2v", "Dimate", inventory.Dimate),
+		fmt.Sprintf("%13v: %2v", "Trimate", inventory.Trimate),
+		fmt.Sprintf("%13v: %2v", "Monofluid", inventory.Monofluid),
+		fmt.Sprintf("%13v: %2v", "Difluid", inventory.Difluid),
+		fmt.Sprintf("%13v: %2v", "Trifluid", inventory.Trifluid),
+		fmt.Sprintf("%13v: %2v", "Moon Atomizer", inventory.MoonAtomizer),
+		fmt.Sprintf("%13v: %2v", "Star Atomizer", inventory.StarAtomizer),
+		fmt.Sprintf("%13v: %2v", "Sol Atomizer", inventory.SolAtomizer),
+		fmt.Sprintf("%13v: %2v", "Telepipe", inventory.Telepipe),
+	}
+}
@@ -0,0 +1,73 @@
+package consoleui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/player"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+func TestFormatPartyRows(t *testing.T) {
+	quest := &pso.QuestRun{
+		// slot 1 is empty, damage and last hits are keyed by slot
+		AllPlayers:   []player.BasePlayerInfo{{Name: "phelix", Class: "HUmar", Slot: 0}, {Name: "ender", Class: "FOnewearl", Slot: 2}},
+		PlayerDamage: map[uint16]int64{0: 3000, 2: 1000},
+		LastHits:     map[uint16]int{0: 7, 2: 12},
+	}
+	rows := formatPartyRows(quest)
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and a row per player but got %v", rows)
+	}
+	if !strings.Contains(rows[1], "phelix") || !strings.Contains(rows[1], "75.0%") || !strings.HasSuffix(rows[1], " 7") {
+		t.Errorf("Unexpected row for phelix '%v'", rows[1])
+	}
+	if !strings.Contains(rows[2], "25.0%") || !strings.HasSuffix(rows[2], " 12") {
+		t.Errorf("Unexpected row for ender '%v'", rows[2])
+	}
+}
+
+func TestFormatMonsterRows_SkipsDeadMonsters(t *testing.T) {
+	monsters := []pso.Monster{
+		{Name: "Booma", Location: model.MonsterLocation{HP: 120, Frozen: true, Paralyzed: true}},
+		{Name: "Gobooma", Location: model.MonsterLocation{HP: 0}},
+		{Name: "Gigobooma", Location: model.MonsterLocation{HP: 300, Confused: true}},
+	}
+	rows := formatMonsterRows(monsters)
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and two living monsters but got %v", rows)
+	}
+	if !strings.HasPrefix(rows[1], "Booma") || !strings.HasSuffix(rows[1], "Frozen, Paralyzed") {
+		t.Errorf("Unexpected row '%v'", rows[1])
+	}
+	if !strings.HasPrefix(rows[2], "Gigobooma") || !strings.HasSuffix(rows[2], "Confused") {
+		t.Errorf("Unexpected row '%v'", rows[2])
+	}
+}
+
+func TestFormatSplitRow(t *testing.T) {
+	start := time.Date(2021, 4, 1, 20, 0, 0, 0, time.UTC)
+	quest := &pso.QuestRun{QuestStartTime: start}
+	split := model.QuestRunSplit{Name: "Forest", Start: start, End: start.Add(95 * time.Second)}
+	compareTo := model.QuestRunSplit{Start: start, End: start.Add(90 * time.Second)}
+	fields := strings.Fields(formatSplitRow(split, quest, &compareTo))
+	expected := []string{"Forest", "1m35s", "1m30s", "+5s", "1m35s"}
+	if strings.Join(fields, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v but got %v", expected, fields)
+	}
+}
+
+func TestNextPage_Wraps(t *testing.T) {
+	cui := &ConsoleUI{}
+	cui.SetPage(PageInventory)
+	cui.NextPage()
+	if cui.page != PageQuest {
+		t.Errorf("Expected to wrap back to the quest page but got %v", cui.page)
+	}
+	cui.SetPage(Page(len(pageNames)))
+	if cui.page != PageQuest {
+		t.Errorf("Expected a missing page to be ignored but got %v", cui.page)
+	}
+}
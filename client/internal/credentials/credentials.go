@@ -0,0 +1,100 @@
+// Keeps the api tokens the client was issued in place of account passwords
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Credential is the api token for one account on one psostats server
+type Credential struct {
+	ServerBaseUrl string
+	User          string
+	Token         string
+}
+
+type Store struct {
+	path        string
+	lock        sync.Mutex
+	credentials []Credential
+}
+
+// Open loads the tokens saved at path, starting empty if there's no file yet
+func Open(path string) (*Store, error) {
+	store := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.credentials); err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	return store, nil
+}
+
+// Token is the token for user on the server at serverBaseUrl. An empty user matches the first token for the server,
+// for configs that only kept the token.
+func (s *Store) Token(serverBaseUrl string, user string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if i := s.find(serverBaseUrl, user); i >= 0 {
+		return s.credentials[i].Token, true
+	}
+	return "", false
+}
+
+func (s *Store) find(serverBaseUrl string, user string) int {
+	for i, credential := range s.credentials {
+		if credential.ServerBaseUrl == serverBaseUrl && (credential.User == user || len(user) == 0) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Save stores token for user on the server at serverBaseUrl, replacing any token it had
+func (s *Store) Save(serverBaseUrl string, user string, token string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	credential := Credential{ServerBaseUrl: serverBaseUrl, User: user, Token: token}
+	if i := s.find(serverBaseUrl, user); i >= 0 && len(user) > 0 {
+		s.credentials[i] = credential
+	} else {
+		s.credentials = append(s.credentials, credential)
+	}
+	return s.save()
+}
+
+// Remove forgets the token for user on the server at serverBaseUrl, after it's been revoked
+func (s *Store) Remove(serverBaseUrl string, user string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := s.find(serverBaseUrl, user)
+	if i < 0 {
+		return nil
+	}
+	s.credentials = append(s.credentials[:i], s.credentials[i+1:]...)
+	return s.save()
+}
+
+// save writes the tokens readable only by the current user, replacing the file so it's never half written
+func (s *Store) save() error {
+	jsonBytes, err := json.Marshal(s.credentials)
+	if err != nil {
+		return err
+	}
+	temp := s.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(temp, jsonBytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, s.path)
+}
@@ -0,0 +1,47 @@
+package credentials
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_SavesTokensByServerAndUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("https://psostats.com", "ephinea-main", "a.secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("https://psostats.com", "unseen-alt", "b.secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("https://psostats.com", "ephinea-main", "c.secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := reopened.Token("https://psostats.com", "ephinea-main"); token != "c.secret" {
+		t.Errorf("Expected the newest token for ephinea-main but got '%v'", token)
+	}
+	if token, _ := reopened.Token("https://psostats.com", "unseen-alt"); token != "b.secret" {
+		t.Errorf("Expected unseen-alt's token but got '%v'", token)
+	}
+	if _, found := reopened.Token("http://localhost", "unseen-alt"); found {
+		t.Errorf("Tokens shouldn't be shared between servers")
+	}
+	if token, _ := reopened.Token("https://psostats.com", ""); token != "c.secret" {
+		t.Errorf("Expected the first token for the server without a user but got '%v'", token)
+	}
+
+	if err := reopened.Remove("https://psostats.com", "ephinea-main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := reopened.Token("https://psostats.com", "ephinea-main"); found {
+		t.Errorf("Expected the removed token to be gone")
+	}
+}
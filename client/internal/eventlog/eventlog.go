@@ -0,0 +1,146 @@
+// Writes client events as newline delimited json for running without the console ui
+package eventlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+type EventType string
+
+const (
+	ConnectedEvent    = EventType("connected")
+	DisconnectedEvent = EventType("disconnected")
+	UploadedEvent     = EventType("uploaded")
+	UploadFailedEvent = EventType("uploadFailed")
+	StoppedEvent      = EventType("stopped")
+)
+
+// Event is one line of the log. Quest events keep the pso.QuestEventType they were sent with.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Connection status on connected and disconnected events
+	Status     string `json:",omitempty"`
+	QuestName  string `json:",omitempty"`
+	Players    int    `json:",omitempty"`
+	PbCategory bool   `json:",omitempty"`
+	// Seconds since the quest started
+	Second int                  `json:",omitempty"`
+	Split  *model.QuestRunSplit `json:",omitempty"`
+	// Where the uploaded game can be viewed
+	Url    string `json:",omitempty"`
+	Pb     bool   `json:",omitempty"`
+	Record bool   `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+type Log struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+	now     func() time.Time
+}
+
+// New writes events to w
+func New(w io.Writer) *Log {
+	return &Log{encoder: json.NewEncoder(w), now: time.Now}
+}
+
+// Open appends events to the file at path, or writes them to stdout when path is empty
+func Open(path string) (*Log, error) {
+	if len(path) == 0 || path == "-" {
+		return New(os.Stdout), nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	eventLog := New(file)
+	eventLog.closer = file
+	return eventLog, nil
+}
+
+// Close closes the file events are written to, events written to stdout are left open
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// Write stamps event with the current time if it doesn't have one and writes it as a line of json
+func (l *Log) Write(event Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if event.Time.IsZero() {
+		event.Time = l.now()
+	}
+	if err := l.encoder.Encode(event); err != nil {
+		log.Printf("Unable to write %v event %v", event.Type, err)
+	}
+}
+
+// SendEvent logs a quest event, it's a pso.QuestEventListener
+func (l *Log) SendEvent(event pso.QuestEvent) {
+	l.Write(Event{
+		Type:       EventType(event.Type),
+		Time:       event.Time,
+		QuestName:  event.QuestName,
+		Players:    event.Players,
+		PbCategory: event.PbCategory,
+		Second:     event.Second,
+		Split:      event.Split,
+	})
+}
+
+// Connection logs a connected or disconnected event
+func (l *Log) Connection(connected bool, status string) {
+	eventType := DisconnectedEvent
+	if connected {
+		eventType = ConnectedEvent
+	}
+	l.Write(Event{Type: eventType, Status: status})
+}
+
+// Upload logs the result of uploading game, err is nil when the server took it
+func (l *Log) Upload(game pso.QuestRun, response model.PostGameResponse, url string, err error) {
+	event := Event{
+		Type:       UploadedEvent,
+		QuestName:  game.QuestName,
+		Players:    len(game.AllPlayers),
+		PbCategory: game.PbCategory,
+		Url:        url,
+		Pb:         response.Pb,
+		Record:     response.Record,
+	}
+	if err != nil {
+		event = Event{
+			Type:       UploadFailedEvent,
+			QuestName:  game.QuestName,
+			Players:    len(game.AllPlayers),
+			PbCategory: game.PbCategory,
+			Error:      err.Error(),
+		}
+	}
+	l.Write(event)
+}
+
+// Stopped logs the client shutting down, err is why if it didn't stop cleanly
+func (l *Log) Stopped(err error) {
+	event := Event{Type: StoppedEvent}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	l.Write(event)
+}
@@ -0,0 +1,67 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+func readEvents(buffer *bytes.Buffer, t *testing.T) []Event {
+	events := make([]Event, 0)
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		event := Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Line '%v' wasn't json: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestLog_WritesOneEventPerLine(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	eventLog := New(buffer)
+	now := time.Date(2021, 4, 1, 20, 0, 0, 0, time.UTC)
+	eventLog.now = func() time.Time { return now }
+
+	split := model.QuestRunSplit{Name: "Forest", EndSecond: 95}
+	eventLog.Connection(true, "Connected to Ephinea")
+	eventLog.SendEvent(pso.QuestEvent{Type: pso.QuestStartEvent, Time: now.Add(time.Minute), QuestName: "Maximum Attack E: Forest", Players: 1})
+	eventLog.SendEvent(pso.QuestEvent{Type: pso.SplitEvent, Time: now.Add(3 * time.Minute), QuestName: "Maximum Attack E: Forest", Players: 1, Second: 95, Split: &split})
+	eventLog.Upload(pso.QuestRun{QuestName: "Maximum Attack E: Forest"}, model.PostGameResponse{Pb: true, Id: "12"}, "https://psostats.com/game/12", nil)
+	eventLog.Upload(pso.QuestRun{QuestName: "Maximum Attack E: Forest"}, model.PostGameResponse{}, "", errors.New("got response status 500"))
+	eventLog.Stopped(nil)
+
+	events := readEvents(buffer, t)
+	expectedTypes := []EventType{ConnectedEvent, "questStart", "split", UploadedEvent, UploadFailedEvent, StoppedEvent}
+	if len(events) != len(expectedTypes) {
+		t.Fatalf("Expected %v events but got %v", len(expectedTypes), len(events))
+	}
+	for i, eventType := range expectedTypes {
+		if events[i].Type != eventType {
+			t.Errorf("Expected event %v to be %v but was %v", i, eventType, events[i].Type)
+		}
+	}
+	if events[0].Status != "Connected to Ephinea" || !events[0].Time.Equal(now) {
+		t.Errorf("Connected event was %+v", events[0])
+	}
+	if !events[1].Time.Equal(now.Add(time.Minute)) {
+		t.Errorf("Quest events should keep their own time, got %v", events[1].Time)
+	}
+	if events[2].Split == nil || events[2].Split.Name != "Forest" || events[2].Second != 95 {
+		t.Errorf("Split event was %+v", events[2])
+	}
+	if !events[3].Pb || events[3].Url != "https://psostats.com/game/12" {
+		t.Errorf("Uploaded event was %+v", events[3])
+	}
+	if events[4].Error != "got response status 500" {
+		t.Errorf("Upload failed event was %+v", events[4])
+	}
+}
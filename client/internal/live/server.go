@@ -0,0 +1,230 @@
+// Serves live game data on localhost so stream overlays can show it
+package live
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/player"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// Messages queued for a stream that isn't keeping up are dropped past this
+const streamBuffer = 32
+
+//go:embed overlay.html
+var overlayPage []byte
+
+// Snapshot is everything an overlay draws
+type Snapshot struct {
+	GameState      pso.GameState
+	Player         player.BasePlayerInfo
+	DataFrame      *model.DataFrame
+	Splits         []model.QuestRunSplit
+	CompareTo      []model.QuestRunSplit
+	DeathCount     int
+	MonstersKilled int
+}
+
+// Message is one json message on the stream, either a snapshot or a quest event
+type Message struct {
+	Type     string
+	Snapshot *Snapshot       `json:",omitempty"`
+	Event    *pso.QuestEvent `json:",omitempty"`
+}
+
+type Server struct {
+	snapshot       func() Snapshot
+	interval       time.Duration
+	allowedOrigins []string
+	lock           sync.Mutex
+	streams        map[chan []byte]struct{}
+	httpServer     *http.Server
+}
+
+// New serves snapshots from snapshot, streams get a new one every interval. Browsers can only read live data from
+// pages served by localhost and allowedOrigins, so other sites open in the same browser can't.
+func New(snapshot func() Snapshot, interval time.Duration, allowedOrigins []string) *Server {
+	return &Server{
+		snapshot:       snapshot,
+		interval:       interval,
+		allowedOrigins: allowedOrigins,
+		streams:        make(map[chan []byte]struct{}),
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveOverlay)
+	mux.HandleFunc("/snapshot", s.serveSnapshot)
+	mux.HandleFunc("/stream", s.serveStream)
+	return mux
+}
+
+// ListenAndServe blocks until the server is closed
+func (s *Server) ListenAndServe(address string) error {
+	s.lock.Lock()
+	s.httpServer = &http.Server{Addr: address, Handler: s.Handler()}
+	httpServer := s.httpServer
+	s.lock.Unlock()
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("ListenAndServe: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for stream := range s.streams {
+		close(stream)
+		delete(s.streams, stream)
+	}
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Close()
+}
+
+// SendEvent pushes event to every open stream, it's a pso.QuestEventListener
+func (s *Server) SendEvent(event pso.QuestEvent) {
+	message, err := json.Marshal(Message{Type: "event", Event: &event})
+	if err != nil {
+		log.Printf("Unable to encode %v event %v", event.Type, err)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for stream := range s.streams {
+		select {
+		case stream <- message:
+		default:
+		}
+	}
+}
+
+func (s *Server) serveOverlay(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(overlayPage)
+}
+
+// originAllowed is whether the page making the request may read live data. Requests without an Origin don't come
+// from a web page.
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+	for _, allowed := range s.allowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	originUrl, err := url.Parse(origin)
+	if err != nil || (originUrl.Scheme != "http" && originUrl.Scheme != "https") {
+		return false
+	}
+	host := originUrl.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	jsonBytes, err := json.Marshal(s.snapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if origin := r.Header.Get("Origin"); len(origin) > 0 {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Vary", "Origin")
+	}
+	w.Write(jsonBytes)
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	stream := s.openStream()
+	defer s.closeStream(stream)
+	closed := make(chan struct{})
+	go func() {
+		conn.readUntilClosed()
+		close(closed)
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	if err := s.writeSnapshot(conn); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.writeSnapshot(conn); err != nil {
+				return
+			}
+		case message, ok := <-stream:
+			if !ok || conn.WriteText(message) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (s *Server) writeSnapshot(conn *websocketConn) error {
+	snapshot := s.snapshot()
+	message, err := json.Marshal(Message{Type: "snapshot", Snapshot: &snapshot})
+	if err != nil {
+		return fmt.Errorf("writeSnapshot: %w", err)
+	}
+	return conn.WriteText(message)
+}
+
+func (s *Server) openStream() chan []byte {
+	stream := make(chan []byte, streamBuffer)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.streams[stream] = struct{}{}
+	return stream
+}
+
+func (s *Server) closeStream(stream chan []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, open := s.streams[stream]; open {
+		close(stream)
+		delete(s.streams, stream)
+	}
+}
@@ -0,0 +1,169 @@
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/pso/player"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+func testSnapshot() Snapshot {
+	return Snapshot{
+		GameState:      pso.GameState{QuestName: "Mop-up Operation #1", QuestStarted: true},
+		Player:         player.BasePlayerInfo{Name: "phelix", HP: 1500, MaxHP: 2012},
+		DataFrame:      &model.DataFrame{HP: 1500, Kills: 12},
+		MonstersKilled: 30,
+	}
+}
+
+func startServer(t *testing.T) (*Server, *httptest.Server) {
+	server := New(testSnapshot, time.Hour, []string{"https://overlays.example.com"})
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(func() {
+		server.Close()
+		httpServer.Close()
+	})
+	return server, httpServer
+}
+
+// dialStream opens a websocket to /stream, returning a reader positioned at the first frame
+func dialStream(httpServer *httptest.Server, t *testing.T) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(httpServer.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET /stream HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %v\r\nSec-WebSocket-Version: 13\r\n\r\n", key)
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected upgrade response %v %v", response.Status, response.Header)
+	}
+	return conn, reader
+}
+
+func readMessage(reader *bufio.Reader, t *testing.T) Message {
+	opcode, payload, err := readFrame(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opcode != opText {
+		t.Fatalf("Expected a text frame but got opcode %v", opcode)
+	}
+	message := Message{}
+	if err := json.Unmarshal(payload, &message); err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func TestServer_Snapshot(t *testing.T) {
+	_, httpServer := startServer(t)
+	response, err := http.Get(httpServer.URL + "/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	snapshot := Snapshot{}
+	if err := json.NewDecoder(response.Body).Decode(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Player.HP != 1500 || snapshot.GameState.QuestName != "Mop-up Operation #1" || snapshot.DataFrame.Kills != 12 {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+}
+
+func TestServer_OnlyAllowedOriginsReadLiveData(t *testing.T) {
+	_, httpServer := startServer(t)
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://localhost:8764", true},
+		{"http://127.0.0.1:8764", true},
+		{"https://overlays.example.com", true},
+		{"https://example.com", false},
+		{"http://localhost.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		for _, path := range []string{"/snapshot", "/stream"} {
+			request, _ := http.NewRequest(http.MethodGet, httpServer.URL+path, nil)
+			if len(tt.origin) > 0 {
+				request.Header.Set("Origin", tt.origin)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+			if forbidden := response.StatusCode == http.StatusForbidden; forbidden == tt.allowed {
+				t.Errorf("Expected %v from '%v' allowed to be %v but got %v", path, tt.origin, tt.allowed, response.Status)
+			}
+		}
+	}
+}
+
+func TestServer_Overlay(t *testing.T) {
+	_, httpServer := startServer(t)
+	response, err := http.Get(httpServer.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected the overlay page but got %v %v", response.Status, response.Header.Get("Content-Type"))
+	}
+}
+
+func TestServer_StreamsSnapshotsAndEvents(t *testing.T) {
+	server, httpServer := startServer(t)
+	conn, reader := dialStream(httpServer, t)
+
+	first := readMessage(reader, t)
+	if first.Type != "snapshot" || first.Snapshot == nil || first.Snapshot.MonstersKilled != 30 {
+		t.Fatalf("Expected a snapshot on connect but got %+v", first)
+	}
+
+	split := model.QuestRunSplit{Name: "Forest", EndSecond: 95}
+	server.SendEvent(pso.QuestEvent{Type: pso.SplitEvent, QuestName: "Mop-up Operation #1", Second: 95, Split: &split})
+	event := readMessage(reader, t)
+	if event.Type != "event" || event.Event == nil || event.Event.Type != pso.SplitEvent || event.Event.Split.Name != "Forest" {
+		t.Errorf("Expected the split event but got %+v", event)
+	}
+
+	if err := writeFrame(conn, opPing, []byte("ping"), []byte{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	opcode, payload, err := readFrame(reader)
+	if err != nil || opcode != opPong || string(payload) != "ping" {
+		t.Errorf("Expected a pong but got %v '%s' %v", opcode, payload, err)
+	}
+}
+
+func TestServer_RejectsPlainRequestsToStream(t *testing.T) {
+	_, httpServer := startServer(t)
+	response, err := http.Get(httpServer.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 but got %v", response.Status)
+	}
+}
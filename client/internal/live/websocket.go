@@ -0,0 +1,167 @@
+package live
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Just enough of RFC 6455 to push text messages to overlay pages
+const (
+	websocketGuid    = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	opText           = 0x1
+	opClose          = 0x8
+	opPing           = 0x9
+	opPong           = 0xA
+	maxControlFrame  = 125
+	maxIncomingFrame = 1 << 16
+)
+
+type websocketConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	writeLock sync.Mutex
+}
+
+func upgrade(w http.ResponseWriter, r *http.Request) (*websocketConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || len(key) == 0 {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("upgrade: not a websocket request")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websockets not supported", http.StatusInternalServerError)
+		return nil, errors.New("upgrade: response can't be hijacked")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	response := fmt.Sprintf("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %v\r\n\r\n", acceptKey(key))
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	return &websocketConn{conn: conn, reader: buf.Reader}, nil
+}
+
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + websocketGuid))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func (c *websocketConn) WriteText(message []byte) error {
+	return c.writeFrame(opText, message)
+}
+
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return writeFrame(c.conn, opcode, payload, nil)
+}
+
+// writeFrame writes one unfragmented frame, servers send unmasked frames and clients pass a mask
+func writeFrame(w io.Writer, opcode byte, payload []byte, mask []byte) error {
+	header := []byte{0x80 | opcode, 0}
+	switch {
+	case len(payload) <= maxControlFrame:
+		header[1] = byte(len(payload))
+	case len(payload) <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+	}
+	if mask != nil {
+		header[1] |= 0x80
+		header = append(header, mask...)
+		masked := make([]byte, len(payload))
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("writeFrame: %w", err)
+	}
+	return nil
+}
+
+// readFrame reads one frame, unmasking it if needed. Overlays don't send fragmented messages so continuations aren't joined.
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(r, extended); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(r, extended); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if length > maxIncomingFrame {
+		return 0, nil, fmt.Errorf("readFrame: %v byte frame is too large", length)
+	}
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(r, mask); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// readUntilClosed answers pings and returns once the client closes the connection or it fails
+func (c *websocketConn) readUntilClosed() {
+	for {
+		opcode, payload, err := readFrame(c.reader)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return
+			}
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return
+		}
+	}
+}
+
+func (c *websocketConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,145 @@
+// Drives a LiveSplit Server timer from quest transitions
+package livesplit
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+)
+
+const (
+	// Commands queued while the connection is busy past this are dropped
+	commandBuffer = 32
+	dialTimeout   = 2 * time.Second
+	minReconnect  = time.Second
+	maxReconnect  = 30 * time.Second
+)
+
+// Client sends LiveSplit Server commands over tcp, one per line
+type Client struct {
+	address    string
+	commands   chan string
+	questStart time.Time
+	// When the last split event arrived, a split on the tick the quest completes already closed the final segment
+	lastSplit time.Time
+	conn      net.Conn
+	backoff   time.Duration
+	nextDial  time.Time
+}
+
+// New connects to the LiveSplit Server at address once Run is started
+func New(address string) *Client {
+	return &Client{
+		address:  address,
+		commands: make(chan string, commandBuffer),
+	}
+}
+
+// SendEvent queues the commands for event, it's a pso.QuestEventListener. The quest timer is sent as game time
+// alongside each split so LiveSplit matches psostats even if its own timer started late. Completing the quest splits
+// once more unless the quest's last split ended with it.
+func (c *Client) SendEvent(event pso.QuestEvent) {
+	switch event.Type {
+	case pso.QuestStartEvent:
+		c.questStart = event.Time
+		c.lastSplit = time.Time{}
+		c.queue("starttimer")
+		c.queue("initgametime")
+		c.queue("setgametime " + formatGameTime(0))
+	case pso.SplitEvent:
+		c.lastSplit = event.Time
+		c.queue("setgametime " + formatGameTime(event.Time.Sub(c.questStart)))
+		c.queue("split")
+	case pso.QuestCompleteEvent:
+		if event.Time.Equal(c.lastSplit) {
+			return
+		}
+		c.queue("setgametime " + formatGameTime(event.Time.Sub(c.questStart)))
+		c.queue("split")
+	case pso.ResetEvent:
+		c.queue("reset")
+	}
+}
+
+func (c *Client) queue(command string) {
+	select {
+	case c.commands <- command:
+	default:
+		log.Printf("LiveSplit command buffer full, dropping %v", command)
+	}
+}
+
+// Run writes queued commands until done is closed. Commands sent while LiveSplit can't be reached are dropped,
+// a late split would be worse than a missing one.
+func (c *Client) Run(done <-chan struct{}) {
+	defer c.disconnect()
+	c.connect()
+	for {
+		select {
+		case command := <-c.commands:
+			if !c.connect() {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.conn, "%v\r\n", command); err != nil {
+				log.Printf("Lost connection to LiveSplit at %v, dropping %v: %v", c.address, command, err)
+				c.disconnect()
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// connect dials LiveSplit if there's no connection, backing off after failures
+func (c *Client) connect() bool {
+	if c.conn != nil {
+		return true
+	}
+	if time.Now().Before(c.nextDial) {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", c.address, dialTimeout)
+	if err != nil {
+		if c.backoff == 0 {
+			log.Printf("Unable to connect to LiveSplit at %v: %v", c.address, err)
+		}
+		c.backoff = nextBackoff(c.backoff)
+		c.nextDial = time.Now().Add(c.backoff)
+		return false
+	}
+	log.Printf("Connected to LiveSplit at %v", c.address)
+	c.conn = conn
+	c.backoff = 0
+	c.nextDial = time.Time{}
+	return true
+}
+
+func (c *Client) disconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff < minReconnect {
+		return minReconnect
+	}
+	backoff *= 2
+	if backoff > maxReconnect {
+		return maxReconnect
+	}
+	return backoff
+}
+
+// formatGameTime formats duration the way LiveSplit parses a TimeSpan, h:mm:ss.fff
+func formatGameTime(duration time.Duration) string {
+	if duration < 0 {
+		duration = 0
+	}
+	milliseconds := duration.Milliseconds()
+	return fmt.Sprintf("%d:%02d:%02d.%03d", milliseconds/3600000, milliseconds/60000%60, milliseconds/1000%60, milliseconds%1000)
+}
@@ -0,0 +1,130 @@
+package livesplit
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// fakeLiveSplit accepts one connection and sends each line it reads to lines
+func fakeLiveSplit(t *testing.T) (string, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return listener.Addr().String(), lines
+}
+
+func expectCommands(lines chan string, expected []string, t *testing.T) {
+	for _, command := range expected {
+		select {
+		case line := <-lines:
+			if line != command {
+				t.Errorf("Expected '%v' but got '%v'", command, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for '%v'", command)
+		}
+	}
+}
+
+func TestClient_SendsQuestTransitions(t *testing.T) {
+	address, lines := fakeLiveSplit(t)
+	client := New(address)
+	done := make(chan struct{})
+	defer close(done)
+	go client.Run(done)
+
+	start := time.Date(2021, 4, 1, 20, 0, 0, 0, time.UTC)
+	split := model.QuestRunSplit{Name: "Forest", EndSecond: 95}
+	client.SendEvent(pso.QuestEvent{Type: pso.QuestStartEvent, Time: start})
+	client.SendEvent(pso.QuestEvent{Type: pso.DeathEvent, Time: start.Add(time.Minute)})
+	client.SendEvent(pso.QuestEvent{Type: pso.SplitEvent, Time: start.Add(95500 * time.Millisecond), Split: &split})
+	client.SendEvent(pso.QuestEvent{Type: pso.QuestCompleteEvent, Time: start.Add(time.Hour + 2*time.Minute + 3250*time.Millisecond)})
+	client.SendEvent(pso.QuestEvent{Type: pso.ResetEvent, Time: start.Add(2 * time.Hour)})
+
+	expectCommands(lines, []string{
+		"starttimer",
+		"initgametime",
+		"setgametime 0:00:00.000",
+		"setgametime 0:01:35.500",
+		"split",
+		"setgametime 1:02:03.250",
+		"split",
+		"reset",
+	}, t)
+}
+
+func TestClient_SplitsOnceWhenTheLastSplitEndsWithTheQuest(t *testing.T) {
+	address, lines := fakeLiveSplit(t)
+	client := New(address)
+	done := make(chan struct{})
+	defer close(done)
+	go client.Run(done)
+
+	start := time.Date(2021, 4, 1, 20, 0, 0, 0, time.UTC)
+	end := start.Add(95500 * time.Millisecond)
+	split := model.QuestRunSplit{Name: "Room 6", EndSecond: 95}
+	client.SendEvent(pso.QuestEvent{Type: pso.QuestStartEvent, Time: start})
+	client.SendEvent(pso.QuestEvent{Type: pso.SplitEvent, Time: end, Split: &split})
+	client.SendEvent(pso.QuestEvent{Type: pso.QuestCompleteEvent, Time: end})
+	client.SendEvent(pso.QuestEvent{Type: pso.ResetEvent, Time: start.Add(2 * time.Hour)})
+
+	expectCommands(lines, []string{
+		"starttimer",
+		"initgametime",
+		"setgametime 0:00:00.000",
+		"setgametime 0:01:35.500",
+		"split",
+		"reset",
+	}, t)
+}
+
+func TestClient_DropsCommandsWithoutLiveSplit(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	client := New(address)
+	done := make(chan struct{})
+	defer close(done)
+	go client.Run(done)
+	// SendEvent is called on the memory reading goroutine, it has to return even when nothing is listening
+	for i := 0; i < commandBuffer*2; i++ {
+		client.SendEvent(pso.QuestEvent{Type: pso.ResetEvent})
+	}
+}
+
+func TestFormatGameTime(t *testing.T) {
+	cases := map[time.Duration]string{
+		-time.Second:                  "0:00:00.000",
+		1500 * time.Millisecond:       "0:00:01.500",
+		10*time.Minute + time.Second:  "0:10:01.000",
+		25*time.Hour + 59*time.Minute: "25:59:00.000",
+	}
+	for duration, expected := range cases {
+		if formatted := formatGameTime(duration); formatted != expected {
+			t.Errorf("Expected %v to format as %v but got %v", duration, expected, formatted)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package numbers
+
+import (
+	"encoding/binary"
+	"math"
+	"unicode/utf16"
+)
+
+const fakePageSize = 0x1000
+
+// FakeMemory is an in-memory MemoryReader used to build synthetic pso memory images.
+// Pages are mapped on first write, reads touching an unmapped page fail like they would against a real process.
+type FakeMemory struct {
+	pages map[uintptr][]byte
+}
+
+func NewFakeMemory() *FakeMemory {
+	return &FakeMemory{pages: make(map[uintptr][]byte)}
+}
+
+func (m *FakeMemory) ReadMemory(address uintptr, size uintptr) ([]uint16, bool) {
+	bytes := make([]byte, size+(size%2))
+	for i := uintptr(0); i < size; i++ {
+		page, mapped := m.pages[(address+i)/fakePageSize]
+		if !mapped {
+			return nil, false
+		}
+		bytes[i] = page[(address+i)%fakePageSize]
+	}
+	buf := make([]uint16, size)
+	for i := 0; i < len(bytes)/2; i++ {
+		buf[i] = binary.LittleEndian.Uint16(bytes[2*i:])
+	}
+	return buf, true
+}
+
+func (m *FakeMemory) Write(address uintptr, data []byte) {
+	for i, b := range data {
+		pageNumber := (address + uintptr(i)) / fakePageSize
+		page, mapped := m.pages[pageNumber]
+		if !mapped {
+			page = make([]byte, fakePageSize)
+			m.pages[pageNumber] = page
+		}
+		page[(address+uintptr(i))%fakePageSize] = b
+	}
+}
+
+func (m *FakeMemory) WriteU8(address uintptr, value uint8) {
+	m.Write(address, []byte{value})
+}
+
+func (m *FakeMemory) WriteU16(address uintptr, value uint16) {
+	data := make([]byte, 2)
+	binary.LittleEndian.PutUint16(data, value)
+	m.Write(address, data)
+}
+
+func (m *FakeMemory) WriteU32(address uintptr, value uint32) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	m.Write(address, data)
+}
+
+func (m *FakeMemory) WriteF32(address uintptr, value float32) {
+	m.WriteU32(address, math.Float32bits(value))
+}
+
+// WriteString writes a null terminated utf16 string
+func (m *FakeMemory) WriteString(address uintptr, value string) {
+	for i, c := range append(utf16.Encode([]rune(value)), 0) {
+		m.WriteU16(address+uintptr(2*i), c)
+	}
+}
+
+// Zero maps and clears size bytes starting at address
+func (m *FakeMemory) Zero(address uintptr, size int) {
+	m.Write(address, make([]byte, size))
+}
@@ -0,0 +1,7 @@
+package numbers
+
+// MemoryReader reads blocks of pso process memory. Buffers are shaped like w32.ReadProcessMemory's:
+// a read of n bytes returns n little-endian 16-bit words, of which the first (n+1)/2 hold the data.
+type MemoryReader interface {
+	ReadMemory(address uintptr, size uintptr) ([]uint16, bool)
+}
@@ -0,0 +1,53 @@
+package numbers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// ProcMemory reads memory from another linux process, such as pso running under wine.
+// Reads use process_vm_readv and fall back to /proc/<pid>/mem where that syscall isn't allowed.
+type ProcMemory struct {
+	Pid int
+	mem *os.File
+}
+
+func OpenProcMemory(pid int) (*ProcMemory, error) {
+	mem, err := os.Open(fmt.Sprintf("/proc/%d/mem", pid))
+	if err != nil {
+		return nil, fmt.Errorf("OpenProcMemory: %w", err)
+	}
+	return &ProcMemory{Pid: pid, mem: mem}, nil
+}
+
+func (m *ProcMemory) ReadMemory(address uintptr, size uintptr) ([]uint16, bool) {
+	bytes := make([]byte, size+(size%2))
+	if size > 0 && !m.readv(address, bytes[:size]) && !m.readMem(address, bytes[:size]) {
+		return nil, false
+	}
+	buf := make([]uint16, size)
+	for i := 0; i < len(bytes)/2; i++ {
+		buf[i] = binary.LittleEndian.Uint16(bytes[2*i:])
+	}
+	return buf, true
+}
+
+func (m *ProcMemory) readv(address uintptr, bytes []byte) bool {
+	local := []unix.Iovec{{Base: &bytes[0]}}
+	local[0].SetLen(len(bytes))
+	remote := []unix.RemoteIovec{{Base: address, Len: len(bytes)}}
+	n, err := unix.ProcessVMReadv(m.Pid, local, remote, 0)
+	return err == nil && n == len(bytes)
+}
+
+func (m *ProcMemory) readMem(address uintptr, bytes []byte) bool {
+	n, err := m.mem.ReadAt(bytes, int64(address))
+	return err == nil && n == len(bytes)
+}
+
+func (m *ProcMemory) Close() error {
+	return m.mem.Close()
+}
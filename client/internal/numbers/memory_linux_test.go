@@ -0,0 +1,100 @@
+package numbers_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+)
+
+const helperEnv = "PSOSTATS_MEMORY_HELPER"
+
+// helperBytes is what the helper process maps, a u16, a u32 and a utf16 string
+var helperBytes = []byte{0x34, 0x12, 0x78, 0x56, 0x34, 0x12, 'P', 0, 'S', 0, 'O', 0, 0, 0, 0xAB}
+
+// TestProcMemory_HelperProcess isn't a real test, it's the process the other tests read from.
+// It prints the address of helperBytes and waits for stdin to close.
+func TestProcMemory_HelperProcess(t *testing.T) {
+	if os.Getenv(helperEnv) != "1" {
+		return
+	}
+	data := append([]byte(nil), helperBytes...)
+	fmt.Printf("%d\n", uintptr(unsafe.Pointer(&data[0])))
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Println(data[0])
+	os.Exit(0)
+}
+
+func startHelper(t *testing.T) (*numbers.ProcMemory, uintptr) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestProcMemory_HelperProcess")
+	cmd.Env = append(os.Environ(), helperEnv+"=1")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading helper address: %v", err)
+	}
+	address, err := strconv.ParseUint(strings.TrimSpace(line), 10, 64)
+	if err != nil {
+		t.Fatalf("parsing helper address %q: %v", line, err)
+	}
+	memory, err := numbers.OpenProcMemory(cmd.Process.Pid)
+	if err != nil {
+		t.Skipf("Not allowed to read other processes here: %v", err)
+	}
+	t.Cleanup(func() { memory.Close() })
+	return memory, uintptr(address)
+}
+
+func TestProcMemory_ReadsHelperProcess(t *testing.T) {
+	memory, address := startHelper(t)
+
+	if value := numbers.ReadU16(memory, address); value != 0x1234 {
+		t.Errorf("Expected 0x1234 but got 0x%04x", value)
+	}
+	if value, err := numbers.ReadU32(memory, address+2); err != nil || value != 0x12345678 {
+		t.Errorf("Expected 0x12345678 but got 0x%08x %v", value, err)
+	}
+	if value, err := numbers.ReadNullTerminatedString(memory, address+6); err != nil || value != "PSO" {
+		t.Errorf("Expected 'PSO' but got '%v' %v", value, err)
+	}
+}
+
+func TestProcMemory_OddSizedRead(t *testing.T) {
+	memory, address := startHelper(t)
+
+	buf, ok := memory.ReadMemory(address+12, 3)
+	if !ok {
+		t.Fatal("Read failed")
+	}
+	if len(buf) != 3 || buf[0] != 0x0000 || buf[1] != 0x00AB {
+		t.Errorf("Expected the w32 buffer shape [0x0000 0x00AB 0] but got %x", buf)
+	}
+}
+
+func TestProcMemory_UnmappedRead(t *testing.T) {
+	memory, _ := startHelper(t)
+
+	if _, ok := memory.ReadMemory(0, 4); ok {
+		t.Error("Expected reading address 0 to fail")
+	}
+}
@@ -0,0 +1,15 @@
+package numbers
+
+import (
+	"github.com/TheTitanrain/w32"
+)
+
+// W32Memory reads memory from a process opened with w32.OpenProcess
+type W32Memory struct {
+	Handle w32.HANDLE
+}
+
+func (m W32Memory) ReadMemory(address uintptr, size uintptr) ([]uint16, bool) {
+	buf, _, ok := w32.ReadProcessMemory(m.Handle, address, size)
+	return buf, ok
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"github.com/TheTitanrain/w32"
 	"log"
 	"math"
 	"unicode/utf16"
@@ -24,8 +23,8 @@ func Float32FromU16(lsb uint16, msb uint16) float32 {
 	return math.Float32frombits(combinedValue)
 }
 
-func ReadString(handle w32.HANDLE, address uintptr, length int) (string, error) {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, uintptr(length*2))
+func ReadString(memory MemoryReader, address uintptr, length int) (string, error) {
+	buf, ok := memory.ReadMemory(address, uintptr(length*2))
 	if !ok {
 		return "", errors.New(fmt.Sprintf("Unable to read string at 0x%08x", address))
 	}
@@ -40,8 +39,8 @@ func ReadString(handle w32.HANDLE, address uintptr, length int) (string, error)
 	return byteBuf.String(), nil
 }
 
-func ReadNullTerminatedString(handle w32.HANDLE, address uintptr) (string, error) {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, 48)
+func ReadNullTerminatedString(memory MemoryReader, address uintptr) (string, error) {
+	buf, ok := memory.ReadMemory(address, 48)
 	if !ok {
 		return "", errors.New(fmt.Sprintf("Unable to read string at 0x%08x", address))
 	}
@@ -57,48 +56,48 @@ func ReadNullTerminatedString(handle w32.HANDLE, address uintptr) (string, error
 	return name, nil
 }
 
-func ReadI8(handle w32.HANDLE, address uintptr) int8 {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, 1)
+func ReadI8(memory MemoryReader, address uintptr) int8 {
+	buf, ok := memory.ReadMemory(address, 1)
 	if !ok {
 		log.Fatalf("Error reading 0x%08x", address)
 	}
 	return int8(buf[0])
 }
 
-func ReadU8(handle w32.HANDLE, address uintptr) uint8 {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, 1)
+func ReadU8(memory MemoryReader, address uintptr) uint8 {
+	buf, ok := memory.ReadMemory(address, 1)
 	if !ok {
 		log.Fatalf("Error reading 0x%08x", address)
 	}
 	return uint8(buf[0])
 }
 
-func ReadU16(handle w32.HANDLE, address uintptr) uint16 {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, 2)
+func ReadU16(memory MemoryReader, address uintptr) uint16 {
+	buf, ok := memory.ReadMemory(address, 2)
 	if !ok {
 		log.Fatalf("Unable to readU16 @0x%08x", address)
 	}
 	return buf[0]
 }
 
-func ReadU32Unchecked(handle w32.HANDLE, address uintptr) uint32 {
-	ret, err := ReadU32(handle, address)
+func ReadU32Unchecked(memory MemoryReader, address uintptr) uint32 {
+	ret, err := ReadU32(memory, address)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
 	return ret
 }
 
-func ReadU32(handle w32.HANDLE, address uintptr) (uint32, error) {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, 4)
+func ReadU32(memory MemoryReader, address uintptr) (uint32, error) {
+	buf, ok := memory.ReadMemory(address, 4)
 	if !ok {
 		return 0, errors.New(fmt.Sprintf("Unable to readU32 0x%08x", address))
 	}
 	return Uint32From16(buf[0:2]), nil
 }
 
-func ReadF32(handle w32.HANDLE, address uintptr) float32 {
-	buf, _, ok := w32.ReadProcessMemory(handle, address, 4)
+func ReadF32(memory MemoryReader, address uintptr) float32 {
+	buf, ok := memory.ReadMemory(address, 4)
 	if !ok {
 		log.Fatalf("Unable to read f32 @0x%08x", address)
 	}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/phelix-/psostats/v2/pkg/model"
 
-	"github.com/TheTitanrain/w32"
 	"github.com/phelix-/psostats/v2/client/internal/numbers"
 	"github.com/phelix-/psostats/v2/client/internal/pso/inventory"
 	"github.com/phelix-/psostats/v2/client/internal/pso/player"
@@ -19,8 +18,6 @@ import (
 )
 
 const (
-	basePlayerArrayAddress   = uintptr(0x00A94254)
-	myPlayerIndexAddress     = 0x00A9C4F4
 	bPDeRolLeData            = 0x00A43CC8
 	monsterDeRolLeHP         = 0x6B4
 	monsterDeRolLeHPMax      = 0x6B0
@@ -64,6 +61,7 @@ func (game *BaseGameInfo) DifficultyString() string {
 type Monster struct {
 	Name            string
 	hp              uint16
+	overkill        uint16 // how far below zero hp went on the killing blow, when the server lets it
 	Id              uint16
 	UnitxtId        uint32
 	SpawnTime       time.Time
@@ -73,6 +71,35 @@ type Monster struct {
 	Index           int
 	LastAttackerIdx uint16
 	Location        model.MonsterLocation
+	MaxHp           uint16 // highest hp seen while alive, usually the hp it spawned with
+	DamageByPlayer  map[uint16]int64
+	KilledBy        uint16
+	TimeToKill      time.Duration
+	Overkill        uint16
+	FrozenTime      time.Duration
+	ConfusedTime    time.Duration
+	ParalyzedTime   time.Duration
+	Freezes         int
+	Confusions      int
+	Paralyses       int
+}
+
+// PlayerInSlot is the player in party slot, AllPlayers skips empty slots so it can't be indexed by slot
+func (questRun *QuestRun) PlayerInSlot(slot uint16) (player.BasePlayerInfo, bool) {
+	for _, p := range questRun.AllPlayers {
+		if uint16(p.Slot) == slot {
+			return p, true
+		}
+	}
+	return player.BasePlayerInfo{}, false
+}
+
+// creditDamage adds damage to what the player in party slot playerIdx has dealt to the monster
+func (monster *Monster) creditDamage(playerIdx uint16, damage int64) {
+	if monster.DamageByPlayer == nil {
+		monster.DamageByPlayer = make(map[uint16]int64)
+	}
+	monster.DamageByPlayer[playerIdx] += damage
 }
 
 type Event struct {
@@ -149,12 +176,31 @@ type QuestRun struct {
 	previousState            uint16
 	TimeByState              map[uint16]uint64
 	TechsCast                map[string]int
+	TechCasts                []model.TechCast
+	ItemPickups              []model.ItemPickup
+	itemsSeen                map[string]bool
+	PhotonBlasts             []model.PhotonBlast
+	previousTickPb           float32
+	StatusEffects            model.StatusEffects
+	TrapUses                 []model.TrapUse
+	previousTickFt           uint16
+	previousTickCt           uint16
+	lastMonsterTick          time.Time
+	previousTickTp           uint16
 	TimeStanding             uint64
 	TimeMoving               uint64
 	TimeAttacking            uint64
 	TimeCasting              uint64
 	Points                   uint16
 	DataFrames               []model.DataFrame
+	// Frames per second sampled into DataFrameDeltas, zero when one full frame a second goes to DataFrames
+	DataFrameRate   int
+	DataFrameDeltas []model.DataFrameDelta
+	lastDataFrame   model.DataFrame
+	lastFrameIndex  int
+	// Attempts and resets at this quest and category before it was finished, set when uploadAttempts is enabled
+	Attempts int
+	Resets   int
 }
 
 func (pso *PSO) StartNewQuest(questConfig quest.Quest) {
@@ -164,7 +210,7 @@ func (pso *PSO) StartNewQuest(questConfig quest.Quest) {
 	if err != nil {
 		log.Panicf("unable to get all players %v", err)
 	}
-	questStartTime := time.Now()
+	questStartTime := pso.tickTime
 	pso.GameState.QuestStartTime = questStartTime
 
 	maxPartySupplyableShifta := int16(0)
@@ -237,16 +283,26 @@ func (pso *PSO) StartNewQuest(questConfig quest.Quest) {
 		TPUsed:                   0,
 		TimeByState:              make(map[uint16]uint64),
 		TechsCast:                make(map[string]int),
+		TechCasts:                make([]model.TechCast, 0),
+		ItemPickups:              make([]model.ItemPickup, 0),
+		itemsSeen:                make(map[string]bool),
+		PhotonBlasts:             make([]model.PhotonBlast, 0),
+		TrapUses:                 make([]model.TrapUse, 0),
 		DataFrames:               make([]model.DataFrame, 0),
+		DataFrameRate:            pso.dataFrameRate,
+		DataFrameDeltas:          make([]model.DataFrameDelta, 0),
+		lastFrameIndex:           -1,
 	}
 	pso.startedGame <- pso.CurrentQuest
 	pso.GameState.QuestStarted = true
+	pso.GameState.waveStart = questStartTime
+	pso.sendQuestEvent(QuestStartEvent, nil)
 }
 
 func (pso *PSO) consolidateFrame(monsters []Monster) {
 
 	currentQuestRun := pso.CurrentQuest
-	currentSecond := int(time.Now().Sub(currentQuestRun.QuestStartTime).Seconds())
+	currentSecond := int(pso.tickTime.Sub(currentQuestRun.QuestStartTime).Seconds())
 	if currentQuestRun.QuestComplete {
 		return
 	}
@@ -335,44 +391,19 @@ func (pso *PSO) consolidateFrame(monsters []Monster) {
 		if pso.CurrentPlayerData.ShiftaLvl > currentQuestRun.maxPartyPbShifta {
 			currentQuestRun.IllegalShifta = true
 		}
-		dataFrame := model.DataFrame{
-			Time:               time.Now().Unix(),
-			HP:                 pso.CurrentPlayerData.HP,
-			TP:                 pso.CurrentPlayerData.TP,
-			PB:                 pso.CurrentPlayerData.PB,
-			MesetaCharged:      mesetaCharged,
-			ShiftaLvl:          pso.CurrentPlayerData.ShiftaLvl,
-			DebandLvl:          pso.CurrentPlayerData.DebandLvl,
-			Invincible:         pso.CurrentPlayerData.InvincibilityFrames > 0,
-			Map:                pso.GameState.Map,
-			MapVariation:       pso.GameState.MapVariation,
-			FT:                 pso.CurrentPlayerData.FreezeTraps,
-			DT:                 pso.CurrentPlayerData.DamageTraps,
-			CT:                 pso.CurrentPlayerData.ConfuseTraps,
-			DamageDealt:        damageDealt,
-			State:              pso.CurrentPlayerData.ActionState,
-			Weapon:             pso.Inventory.EquippedWeapon.Id,
-			Kills:              pso.CurrentQuest.LastHits[uint16(pso.CurrentPlayerIndex)],
-			PlayerByGcLocation: make(map[string]model.Location),
-			MonsterLocation:    make(map[int]model.MonsterLocation),
+		if pso.dataFrameRate == 0 {
+			currentQuestRun.DataFrames = append(currentQuestRun.DataFrames, pso.readDataFrame(&currentQuestRun, monsters))
 		}
-		for _, monster := range monsters {
-			if monster.hp > 0 {
-				dataFrame.MonsterLocation[int(monster.Id)] = monster.Location
-				dataFrame.MonstersAlive++
-			}
-		}
-		if players, err := pso.getOtherPlayers(); err == nil {
-			playerByGcLocation := dataFrame.PlayerByGcLocation
-			for _, player := range players {
-				if player.Warping && pso.ephineaFastBurstEnabled() {
-					currentQuestRun.FastWarps = true
-				}
-				playerByGcLocation[player.GuildCard] = player.Location
-			}
-			dataFrame.PlayerByGcLocation = playerByGcLocation
+	}
+	if pso.dataFrameRate > 0 {
+		frameIndex := int(pso.tickTime.Sub(currentQuestRun.QuestStartTime) * time.Duration(pso.dataFrameRate) / time.Second)
+		if frameIndex > currentQuestRun.lastFrameIndex {
+			currentQuestRun.lastFrameIndex = frameIndex
+			dataFrame := pso.readDataFrame(&currentQuestRun, monsters)
+			dataFrame.TimeMillis = pso.tickTime.UnixMilli()
+			currentQuestRun.DataFrameDeltas = append(currentQuestRun.DataFrameDeltas, model.EncodeDataFrame(currentQuestRun.lastDataFrame, dataFrame))
+			currentQuestRun.lastDataFrame = dataFrame
 		}
-		currentQuestRun.DataFrames = append(currentQuestRun.DataFrames, dataFrame)
 	}
 
 	currentState := pso.CurrentPlayerData.ActionState
@@ -391,6 +422,14 @@ func (pso *PSO) consolidateFrame(monsters []Monster) {
 		if currentQuestRun.previousState != 8 {
 			tech := pso.CurrentPlayerData.GetCurrentTech()
 			currentQuestRun.TechsCast[tech] = currentQuestRun.TechsCast[tech] + 1
+			currentQuestRun.TechCasts = append(currentQuestRun.TechCasts, model.TechCast{
+				Second:  currentSecond,
+				Time:    pso.tickTime,
+				Tech:    tech,
+				Level:   pso.CurrentPlayerData.GetCurrentTechLevel(),
+				Weapon:  pso.Inventory.EquippedWeapon.Display,
+				TpStart: currentQuestRun.previousTickTp,
+			})
 			if found {
 				currentWeapon.Techs = currentWeapon.Techs + 1
 				currentQuestRun.Weapons[pso.Inventory.EquippedWeapon.Id] = currentWeapon
@@ -399,7 +438,14 @@ func (pso *PSO) consolidateFrame(monsters []Monster) {
 	} else if currentState == 1 {
 		currentQuestRun.TimeStanding++
 	}
+	if currentState == 8 || currentQuestRun.previousState == 8 {
+		// TP comes off partway through the cast, so the drop is taken until the tick after casting ends
+		if cast := len(currentQuestRun.TechCasts) - 1; cast >= 0 {
+			currentQuestRun.TechCasts[cast].AddTp(pso.CurrentPlayerData.TP)
+		}
+	}
 	currentQuestRun.previousState = currentState
+	currentQuestRun.previousTickTp = pso.CurrentPlayerData.TP
 
 	if players, err := pso.getOtherPlayers(); err == nil {
 		for _, player := range players {
@@ -439,6 +485,22 @@ func (pso *PSO) consolidateFrame(monsters []Monster) {
 	if pso.Inventory.Telepipe+1 == currentQuestRun.previousInventory.Telepipe {
 		currentQuestRun.TelepipeUsed++
 	}
+	// Items bought on pioneer 2 aren't drops, and there's nothing to compare to on the first tick
+	if currentQuestRun.previousInventory.Items != nil && pso.CurrentPlayerData.Floor > 0 {
+		for _, item := range pso.Inventory.PickedUp(currentQuestRun.previousInventory) {
+			if item.Type != 3 && currentQuestRun.itemsSeen[item.Id] {
+				// dropped and picked back up
+				continue
+			}
+			pickup := inventory.DescribeItem(pso.process, pso.offsets, item)
+			pickup.Second = currentSecond
+			pickup.Time = pso.tickTime
+			currentQuestRun.ItemPickups = append(currentQuestRun.ItemPickups, pickup)
+		}
+	}
+	for _, item := range pso.Inventory.Items {
+		currentQuestRun.itemsSeen[item.Id] = true
+	}
 	currentQuestRun.previousInventory = pso.Inventory
 
 	if currentQuestRun.lastFloor != pso.GameState.Floor {
@@ -449,12 +511,40 @@ func (pso *PSO) consolidateFrame(monsters []Monster) {
 		currentQuestRun.lastFloor = pso.GameState.Floor
 	}
 
+	// A full gauge emptying is a blast being fired
+	if currentQuestRun.previousTickPb >= 100 && pso.CurrentPlayerData.PB < 100 && pso.CurrentPlayerData.HP > 0 {
+		photonBlast := model.PhotonBlast{
+			Second: currentSecond,
+			Time:   pso.tickTime,
+		}
+		if mag := pso.Inventory.EquippedMag; mag != nil {
+			photonBlast.Blast = mag.PhotonBlast()
+			photonBlast.MagBlasts = mag.PhotonBlasts
+			photonBlast.Mag = mag.Name
+			photonBlast.Sync = mag.Sync
+			photonBlast.IQ = mag.IQ
+		}
+		currentQuestRun.PhotonBlasts = append(currentQuestRun.PhotonBlasts, photonBlast)
+		description := "Photon Blast"
+		if len(photonBlast.Blast) > 0 {
+			description = fmt.Sprintf("Photon Blast: %v", photonBlast.Blast)
+		} else if len(photonBlast.MagBlasts) > 0 {
+			description = fmt.Sprintf("Photon Blast (mag has %v)", strings.Join(photonBlast.MagBlasts, ", "))
+		}
+		currentQuestRun.Events = append(currentQuestRun.Events, Event{
+			Second:      currentSecond,
+			Description: description,
+		})
+	}
+	currentQuestRun.previousTickPb = pso.CurrentPlayerData.PB
+
 	if pso.CurrentPlayerData.HP == 0 && currentQuestRun.lastRecordedHp != 0 {
 		currentQuestRun.DeathCount++
 		currentQuestRun.Events = append(currentQuestRun.Events, Event{
 			Second:      currentSecond,
 			Description: "Died",
 		})
+		pso.sendQuestEvent(DeathEvent, nil)
 	}
 	currentQuestRun.lastRecordedHp = pso.CurrentPlayerData.HP
 
@@ -462,29 +552,90 @@ func (pso *PSO) consolidateFrame(monsters []Monster) {
 		currentQuestRun.QuestComplete = true
 		currentQuestRun.QuestEndTime = pso.GameState.QuestEndTime
 		currentQuestRun.QuestDuration = pso.GameState.QuestEndTime.Sub(currentQuestRun.QuestStartTime).String()
-		pso.completeGame <- currentQuestRun
+		// the client holds on to the completed run, so it gets its own splits
+		completedRun := currentQuestRun
+		completedRun.Splits = append([]model.QuestRunSplit(nil), currentQuestRun.Splits...)
+		pso.completeGame <- completedRun
+		pso.sendQuestEvent(QuestCompleteEvent, nil)
 	} else {
-		currentQuestRun.QuestDuration = time.Now().Sub(currentQuestRun.QuestStartTime).String()
+		currentQuestRun.QuestDuration = pso.tickTime.Sub(currentQuestRun.QuestStartTime).String()
 	}
 	pso.CurrentQuest = currentQuestRun
 }
 
+// LatestDataFrame is the last frame sampled, in either encoding
+func (questRun *QuestRun) LatestDataFrame() (model.DataFrame, bool) {
+	if questRun.DataFrameRate > 0 {
+		return questRun.lastDataFrame, len(questRun.DataFrameDeltas) > 0
+	}
+	if frames := len(questRun.DataFrames); frames > 0 {
+		return questRun.DataFrames[frames-1], true
+	}
+	return model.DataFrame{}, false
+}
+
+// readDataFrame samples the current player, party and monsters
+func (pso *PSO) readDataFrame(currentQuestRun *QuestRun, monsters []Monster) model.DataFrame {
+	dataFrame := model.DataFrame{
+		Time:               pso.tickTime.Unix(),
+		HP:                 pso.CurrentPlayerData.HP,
+		TP:                 pso.CurrentPlayerData.TP,
+		PB:                 pso.CurrentPlayerData.PB,
+		MesetaCharged:      currentQuestRun.previousMesetaCharged,
+		ShiftaLvl:          pso.CurrentPlayerData.ShiftaLvl,
+		DebandLvl:          pso.CurrentPlayerData.DebandLvl,
+		Invincible:         pso.CurrentPlayerData.InvincibilityFrames > 0,
+		Map:                pso.GameState.Map,
+		MapVariation:       pso.GameState.MapVariation,
+		FT:                 pso.CurrentPlayerData.FreezeTraps,
+		DT:                 pso.CurrentPlayerData.DamageTraps,
+		CT:                 pso.CurrentPlayerData.ConfuseTraps,
+		DamageDealt:        currentQuestRun.PlayerDamage[uint16(pso.CurrentPlayerIndex)],
+		State:              pso.CurrentPlayerData.ActionState,
+		Weapon:             pso.Inventory.EquippedWeapon.Id,
+		Kills:              pso.CurrentQuest.LastHits[uint16(pso.CurrentPlayerIndex)],
+		PlayerByGcLocation: make(map[string]model.Location),
+		MonsterLocation:    make(map[int]model.MonsterLocation),
+	}
+	for _, monster := range monsters {
+		if monster.hp > 0 {
+			dataFrame.MonsterLocation[int(monster.Id)] = monster.Location
+			dataFrame.MonstersAlive++
+		}
+	}
+	if players, err := pso.getOtherPlayers(); err == nil {
+		playerByGcLocation := dataFrame.PlayerByGcLocation
+		for _, player := range players {
+			if player.Warping && pso.ephineaFastBurstEnabled() {
+				currentQuestRun.FastWarps = true
+			}
+			playerByGcLocation[player.GuildCard] = player.Location
+		}
+		dataFrame.PlayerByGcLocation = playerByGcLocation
+	}
+	return dataFrame
+}
+
 func (pso *PSO) updateCurrentSplit(questConfig quest.Quest) {
 	currentQuestRun := pso.CurrentQuest
 	currentSplit := pso.GameState.CurrentSplit
 	if currentSplit.Index < len(questConfig.Splits) {
 		currentSplitCfg := questConfig.Splits[pso.GameState.CurrentSplit.Index]
-		switched, err := pso.getFloorSwitch(currentSplitCfg.Trigger.Switch, currentSplitCfg.Trigger.Floor)
-		if err == nil && switched {
-			currentSplit.End = time.Now()
+		splitEnded, err := currentSplitCfg.Trigger.Evaluate(triggerState{pso})
+		if err == nil && splitEnded {
+			currentSplit.End = pso.tickTime
+			currentSplit.EndSecond = int(pso.tickTime.Sub(currentQuestRun.QuestStartTime).Seconds())
 			currentQuestRun.Splits[currentSplit.Index] = currentSplit
+			endedSplit := currentSplit
+			pso.sendQuestEvent(SplitEvent, &endedSplit)
 			currentSplit = model.QuestRunSplit{Index: currentSplit.Index + 1}
 			pso.GameState.CurrentSplit = currentSplit
+			pso.GameState.waveStart = pso.tickTime
 		}
 	}
 	if currentSplit.Start.IsZero() && currentSplit.Index < len(questConfig.Splits) {
-		currentSplit.Start = time.Now()
-		currentSplit.StartSecond = int(time.Now().Sub(currentQuestRun.QuestStartTime).Seconds())
+		currentSplit.Start = pso.tickTime
+		currentSplit.StartSecond = int(pso.tickTime.Sub(currentQuestRun.QuestStartTime).Seconds())
 		currentSplit.Name = questConfig.Splits[pso.GameState.CurrentSplit.Index].Name
 		currentQuestRun.Splits[currentSplit.Index] = currentSplit
 		pso.GameState.CurrentSplit = currentSplit
@@ -492,8 +643,8 @@ func (pso *PSO) updateCurrentSplit(questConfig quest.Quest) {
 }
 
 func (pso *PSO) addExtraQuestInfo(questConfig quest.Quest) {
-	if questConfig.Name == "Endless: Episode 1" || "Endless: Episode 2" {
-		points := quest.GetRegisterValue(pso.handle, 51, pso.GameState.questRegisterPointer)
+	if questConfig.Name == "Endless: Episode 1" || questConfig.Name == "Endless: Episode 2" {
+		points := quest.GetRegisterValue(pso.process, 51, pso.GameState.questRegisterPointer)
 		if points > 0 {
 			pso.CurrentQuest.Points = points
 		}
@@ -501,10 +652,17 @@ func (pso *PSO) addExtraQuestInfo(questConfig quest.Quest) {
 }
 
 func (pso *PSO) consolidateMonsterState(monsters []Monster) {
-	now := time.Now()
+	now := pso.tickTime
 	currentQuestRun := pso.CurrentQuest
+	currentSecond := int(now.Sub(currentQuestRun.QuestStartTime).Seconds())
 	monsterHpPool := 0
-	recordThisSecond := len(currentQuestRun.MonsterHpPool)-1 < currentQuestRun.lastRecordedSecond
+	recordThisSecond := len(currentQuestRun.MonsterHpPool)-1 < currentSecond
+	elapsed := time.Duration(0)
+	if !currentQuestRun.lastMonsterTick.IsZero() {
+		elapsed = now.Sub(currentQuestRun.lastMonsterTick)
+	}
+	currentQuestRun.lastMonsterTick = now
+	pso.useTraps(&currentQuestRun)
 	for _, monster := range monsters {
 		monsterId := int(monster.Id)
 		existingMonster, exists := currentQuestRun.Monsters[monsterId]
@@ -512,11 +670,14 @@ func (pso *PSO) consolidateMonsterState(monsters []Monster) {
 		if !exists {
 			monster.SpawnTime = now
 			monster.Alive = true
+			monster.MaxHp = monster.hp
 			currentQuestRun.Monsters[monsterId] = monster
 			existingMonster = monster
 		} else if existingMonster.Alive && monster.hp <= 0 {
 			// We don't allow frame 0 kills because some monsters appear to spawn in with 0 hp.
 			// This could be a synchronization issue w/ pso (data is still initializing when we catch it?)
+			// statuses it had up to now still count, new ones on the killing blow don't
+			currentQuestRun.applyStatusEffects(&existingMonster, existingMonster.Location, now, elapsed)
 			existingMonster.Alive = false
 			currentQuestRun.MonstersDead += 1
 			existingMonster.KilledTime = now
@@ -526,8 +687,7 @@ func (pso *PSO) consolidateMonsterState(monsters []Monster) {
 				existingMonster.UnitxtId != 68 { // recon
 				// Excluding DRL and Dark Gunners because they're buggy
 				playerName := ""
-				if int(monster.LastAttackerIdx) < len(currentQuestRun.AllPlayers) {
-					player := currentQuestRun.AllPlayers[monster.LastAttackerIdx]
+				if player, found := currentQuestRun.PlayerInSlot(monster.LastAttackerIdx); found {
 					playerName = player.Name
 				}
 				existingMonster.Frame1 = existingMonster.KilledTime.Sub(existingMonster.SpawnTime).Milliseconds() < 60
@@ -537,11 +697,19 @@ func (pso *PSO) consolidateMonsterState(monsters []Monster) {
 			}
 			currentQuestRun.LastHits[monster.LastAttackerIdx] = currentQuestRun.LastHits[monster.LastAttackerIdx] + 1
 			currentQuestRun.PlayerDamage[monster.LastAttackerIdx] = currentQuestRun.PlayerDamage[monster.LastAttackerIdx] + int64(existingMonster.hp)
+			existingMonster.creditDamage(monster.LastAttackerIdx, int64(existingMonster.hp))
+			existingMonster.KilledBy = monster.LastAttackerIdx
+			existingMonster.TimeToKill = existingMonster.KilledTime.Sub(existingMonster.SpawnTime)
+			existingMonster.Overkill = monster.overkill
 			currentQuestRun.Monsters[monsterId] = existingMonster
 		} else if existingMonster.Alive {
+			currentQuestRun.applyStatusEffects(&existingMonster, monster.Location, now, elapsed)
 			if monster.hp < existingMonster.hp {
 				hpLost := int64(existingMonster.hp - monster.hp)
 				currentQuestRun.PlayerDamage[monster.LastAttackerIdx] = currentQuestRun.PlayerDamage[monster.LastAttackerIdx] + hpLost
+				existingMonster.creditDamage(monster.LastAttackerIdx, hpLost)
+			} else if monster.hp > existingMonster.MaxHp {
+				existingMonster.MaxHp = monster.hp
 			}
 			existingMonster.hp = monster.hp
 			currentQuestRun.Monsters[monsterId] = existingMonster
@@ -563,7 +731,7 @@ func (pso *PSO) consolidateMonsterState(monsters []Monster) {
 					Id:         existingMonster.Id,
 					UnitxtId:   existingMonster.UnitxtId,
 					SpawnTime:  now,
-					FirstFrame: currentQuestRun.lastRecordedSecond,
+					FirstFrame: currentSecond,
 					Hp:         make([]int, 0),
 				}
 			}
@@ -638,6 +806,12 @@ func isAttackState(state uint16) bool {
 }
 
 func (pso *PSO) RefreshData() error {
+	return pso.refreshDataAt(time.Now())
+}
+
+// refreshDataAt reads a tick of pso memory, every timestamp recorded during the tick is tickTime
+func (pso *PSO) refreshDataAt(tickTime time.Time) error {
+	pso.tickTime = tickTime
 	if !pso.connected {
 		pso.GameState.Clear()
 		log.Fatal("RefreshData: connection to window lost")
@@ -662,13 +836,14 @@ func (pso *PSO) RefreshData() error {
 	pso.GameState.Difficulty = game.DifficultyString()
 
 	if address != 0 {
-		playerData, err := player.GetPlayerData(pso.handle, address, pso.server)
+		playerData, err := player.GetPlayerData(pso.process, address, pso.server)
 		if err != nil {
 			return err
 		}
+		playerData.Slot = index
 		pso.CurrentPlayerData = playerData
 
-		inventory, err := inventory.ReadInventory(pso.handle, index)
+		inventory, err := inventory.ReadInventory(pso.process, pso.offsets, index)
 		if err != nil {
 			return err
 		}
@@ -678,15 +853,16 @@ func (pso *PSO) RefreshData() error {
 		if err != nil {
 			return err
 		}
+		pso.CurrentMonsters = monsters
 
-		questPtr := quest.GetQuestPointer(pso.handle)
+		questPtr := quest.GetQuestPointer(pso.process, pso.offsets)
 		if questPtr != 0 {
 			if questPtr != pso.GameState.questPointer {
-				pso.GameState.questRegisterPointer = quest.GetQuestRegisterPointer(pso.handle, questPtr)
+				pso.GameState.questRegisterPointer = quest.GetQuestRegisterPointer(pso.process, pso.offsets, questPtr)
 				pso.GameState.questPointer = questPtr
 			}
 			questStartConditionsMet := false
-			questDataPtr := quest.GetQuestDataPointer(pso.handle, questPtr)
+			questDataPtr := quest.GetQuestDataPointer(pso.process, pso.offsets, questPtr)
 
 			questName, err := pso.getQuestName(questDataPtr)
 			if err != nil {
@@ -704,7 +880,7 @@ func (pso *PSO) RefreshData() error {
 
 			if !pso.GameState.QuestStarted {
 				if exists && !questConfig.Ignore {
-					quest.GetQuestRegisterPointer(pso.handle, questPtr)
+					quest.GetQuestRegisterPointer(pso.process, pso.offsets, questPtr)
 					questStartConditionsMet, err = pso.checkQuestStartConditions(questConfig)
 					if err != nil {
 						return err
@@ -714,8 +890,14 @@ func (pso *PSO) RefreshData() error {
 					rngSeed := pso.getRngSeed()
 					pso.GameState.RngSeed = rngSeed
 					pso.StartNewQuest(questConfig)
+					pso.consolidateMonsterState(monsters)
+					pso.updateCurrentSplit(questConfig)
 				}
 			} else if !pso.GameState.QuestComplete {
+				// Monsters and splits are brought up to date before the end check so kill triggers see this tick's
+				// kills, and a last split that ends with the quest is stamped before the run is completed
+				pso.consolidateMonsterState(monsters)
+				pso.updateCurrentSplit(questConfig)
 				if exists {
 					questEndConditionsMet, err := pso.checkQuestEndConditions(questConfig)
 					if err != nil {
@@ -723,16 +905,16 @@ func (pso *PSO) RefreshData() error {
 					}
 					if questEndConditionsMet {
 						pso.GameState.QuestComplete = true
-						pso.GameState.QuestEndTime = time.Now()
+						pso.GameState.QuestEndTime = pso.tickTime
 					} else {
 						if pso.GameState.CmodeStage > 0 && pso.GameState.Floor == 0 {
 							// Back to pioneer2, cmode failed
-							pso.GameState.ClearQuest()
+							pso.resetQuest()
 						} else {
 							rngSeed := pso.getRngSeed()
 							if rngSeed != pso.GameState.RngSeed {
 								// unseen quest reset
-								pso.GameState.ClearQuest()
+								pso.resetQuest()
 							}
 						}
 					}
@@ -740,23 +922,23 @@ func (pso *PSO) RefreshData() error {
 			}
 			if pso.GameState.QuestStarted {
 				pso.consolidateFrame(monsters)
-				pso.updateCurrentSplit(questConfig)
 				pso.addExtraQuestInfo(questConfig)
-				pso.consolidateMonsterState(monsters)
 			}
 		} else {
 			pso.GameState.AllowQuestStart = true
-			pso.GameState.ClearQuest()
+			pso.resetQuest()
 		}
 	} else {
+		pso.resetQuest()
 		pso.GameState.Clear()
+		pso.CurrentMonsters = nil
 	}
 
 	return nil
 }
 
 func (pso *PSO) getMyPlayerIndex() (uint8, error) {
-	buf, _, ok := w32.ReadProcessMemory(pso.handle, uintptr(myPlayerIndexAddress), 4)
+	buf, ok := pso.process.ReadMemory(pso.offsets.Addresses.MyPlayerIndex, 4)
 	if !ok {
 		return 0, errors.New("unable to find player index")
 	}
@@ -765,18 +947,18 @@ func (pso *PSO) getMyPlayerIndex() (uint8, error) {
 }
 
 func (pso *PSO) getBaseCharacterAddress(index uint8) uintptr {
-	address := basePlayerArrayAddress + (4 * uintptr(index))
-	return uintptr(numbers.ReadU32Unchecked(pso.handle, address))
+	address := pso.offsets.Addresses.BasePlayerArray + (4 * uintptr(index))
+	return uintptr(numbers.ReadU32Unchecked(pso.process, address))
 }
 
 func (pso *PSO) getMonsterUnitxtAddr() (uintptr, error) {
-	unitxtAddr, err := numbers.ReadU32(pso.handle, uintptr(0x00a9cd50))
+	unitxtAddr, err := numbers.ReadU32(pso.process, pso.offsets.Addresses.UnitxtPointer)
 	if err != nil {
 		return 0, err
 	}
 	monsterUnitxtAddr := uint32(0)
 	if unitxtAddr != 0 {
-		monsterUnitxtAddr, err = numbers.ReadU32(pso.handle, uintptr(unitxtAddr+16))
+		monsterUnitxtAddr, err = numbers.ReadU32(pso.process, uintptr(unitxtAddr+16))
 	}
 	return uintptr(monsterUnitxtAddr), err
 }
@@ -791,11 +973,11 @@ func (pso *PSO) getMonsterName(monsterId uint32) (string, error) {
 	} else if monsterUnitxtAddr == 0 {
 		return "", errors.New("monsterUnitxtAddr is unset")
 	}
-	monsterNameAddr, err := numbers.ReadU32(pso.handle, monsterUnitxtAddr+uintptr(4*monsterId))
+	monsterNameAddr, err := numbers.ReadU32(pso.process, monsterUnitxtAddr+uintptr(4*monsterId))
 	if err != nil {
 		return "", err
 	}
-	buf, _, ok := w32.ReadProcessMemory(pso.handle, uintptr(monsterNameAddr), 32)
+	buf, ok := pso.process.ReadMemory(uintptr(monsterNameAddr), 32)
 	if !ok {
 		return "", errors.New("unable to getMonsterName")
 	}
@@ -817,10 +999,11 @@ func (pso *PSO) getOtherPlayers() ([]player.BasePlayerInfo, error) {
 	for i := 0; i < 12; i++ {
 		address := pso.getBaseCharacterAddress(uint8(i))
 		if address != 0 {
-			playerData, err := player.GetPlayerData(pso.handle, address, pso.server)
+			playerData, err := player.GetPlayerData(pso.process, address, pso.server)
 			if err != nil {
 				return nil, err
 			}
+			playerData.Slot = uint8(i)
 			players = append(players, playerData)
 		}
 	}
@@ -828,21 +1011,21 @@ func (pso *PSO) getOtherPlayers() ([]player.BasePlayerInfo, error) {
 }
 
 func (pso *PSO) getBaseGameInfo() (BaseGameInfo, error) {
-	base := 0x00A9B1C8
-	max := 0x00A9CD68
-	buf, _, ok := w32.ReadProcessMemory(pso.handle, uintptr(base), uintptr((max-base)+2))
+	base := pso.offsets.Addresses.Episode
+	max := pso.offsets.Addresses.Difficulty
+	buf, ok := pso.process.ReadMemory(base, (max-base)+2)
 	if !ok {
 		return BaseGameInfo{}, errors.New("unable to getDifficulty")
 	}
 
-	difficulty := buf[(0x00A9CD68-base)/2]
-	episode := buf[(0x00A9B1C8-base)/2] + 1
+	difficulty := buf[(max-base)/2]
+	episode := buf[0] + 1
 	if episode == 3 {
 		episode = 4
 	}
-	currentMap := numbers.ReadU16(pso.handle, uintptr(0x00AAFC9C))
-	currentFloor := numbers.ReadU16(pso.handle, uintptr(0x00AAFCA0))
-	mapVariation := numbers.ReadU16(pso.handle, uintptr(0x00AAFC98))
+	currentMap := numbers.ReadU16(pso.process, pso.offsets.Addresses.Map)
+	currentFloor := numbers.ReadU16(pso.process, pso.offsets.Addresses.Floor)
+	mapVariation := numbers.ReadU16(pso.process, pso.offsets.Addresses.MapVariation)
 	game := BaseGameInfo{
 		episode:      episode,
 		difficulty:   difficulty,
@@ -854,7 +1037,7 @@ func (pso *PSO) getBaseGameInfo() (BaseGameInfo, error) {
 }
 
 func (pso *PSO) getFloorSwitch(switchId uint16, floor uint16) (bool, error) {
-	buf, _, ok := w32.ReadProcessMemory(pso.handle, uintptr(0xAC9FA0+(32*int(floor))), 32)
+	buf, ok := pso.process.ReadMemory(pso.offsets.Addresses.FloorSwitches+(32*uintptr(floor)), 32)
 	if !ok {
 		return false, errors.New("unable to getFloorSwitches")
 	}
@@ -870,11 +1053,11 @@ func (pso *PSO) getFloorSwitch(switchId uint16, floor uint16) (bool, error) {
 
 // -------------- Quest Data Block -------------- //
 func (pso *PSO) getQuestNumber(questDataPtr uintptr) uint16 {
-	return numbers.ReadU16(pso.handle, questDataPtr+0x10)
+	return numbers.ReadU16(pso.process, questDataPtr+pso.offsets.Quest.Number)
 }
 
 func (pso *PSO) getQuestName(questDataPtr uintptr) (string, error) {
-	buf, _, ok := w32.ReadProcessMemory(pso.handle, questDataPtr+0x18, 64)
+	buf, ok := pso.process.ReadMemory(questDataPtr+pso.offsets.Quest.Name, 64)
 	if !ok {
 		return "", errors.New("unable to getQuestName")
 	}
@@ -891,157 +1074,106 @@ func (pso *PSO) getQuestName(questDataPtr uintptr) (string, error) {
 }
 
 func (pso *PSO) checkQuestStartConditions(questConfig quest.Quest) (bool, error) {
-	questStart := false
-	if questConfig.StartsOnRegister() {
-		registerSet := quest.IsRegisterSet(pso.handle, *questConfig.Start.Register, pso.GameState.questRegisterPointer)
-		if questConfig.GetCmodeStage() > 0 {
-			cmodeFailedRegister := quest.IsRegisterSet(pso.handle, 253, pso.GameState.questRegisterPointer)
-			questStart = registerSet && !cmodeFailedRegister
-		} else {
-			questStart = registerSet
-		}
-	} else if questConfig.TerminalQuest() {
-		switchSet, err := pso.getFloorSwitch(questConfig.Start.Switch, questConfig.Start.Floor)
-		if err != nil {
-			return false, err
-		}
-		questStart = switchSet
-	} else if questConfig.StartsAtWarpIn() {
-		allPlayers, err := pso.getOtherPlayers()
-		if err != nil {
-			log.Panicf("unable to get all players %v", err)
-		}
-		for _, p := range allPlayers {
-			if p.Location.Floor != 0 && !p.Location.Warping {
-				questStart = true
-				break
-			}
-		}
+	questStart, err := questConfig.Start.Evaluate(triggerState{pso})
+	if err != nil {
+		return false, err
+	}
+	if questStart && questConfig.StartsOnRegister() && questConfig.GetCmodeStage() > 0 {
+		cmodeFailedRegister := quest.IsRegisterSet(pso.process, 253, pso.GameState.questRegisterPointer)
+		questStart = !cmodeFailedRegister
 	}
 	return questStart, nil
 }
 
 func (pso *PSO) checkQuestEndConditions(questConfig quest.Quest) (bool, error) {
-	if questConfig.EndsOnRegister() {
-		return quest.IsRegisterSet(pso.handle, *questConfig.End.Register, pso.GameState.questRegisterPointer), nil
-	} else if questConfig.End.Floor != 0 {
-		return pso.getFloorSwitch(questConfig.End.Switch, questConfig.End.Floor)
-	} else {
-		return false, errors.New(fmt.Sprintf("Quest %v ends on neither switch nor register", questConfig.Name))
+	if !questConfig.End.IsSet() {
+		return false, errors.New(fmt.Sprintf("Quest %v has no end trigger", questConfig.Name))
+	}
+	return questConfig.End.Evaluate(triggerState{pso})
+}
+
+// triggerState evaluates quest triggers against the current tick
+type triggerState struct {
+	pso *PSO
+}
+
+func (s triggerState) RegisterValue(register uint16) uint16 {
+	return quest.GetRegisterValue(s.pso.process, register, s.pso.GameState.questRegisterPointer)
+}
+
+func (s triggerState) FloorSwitch(floor uint16, switchId uint16) (bool, error) {
+	return s.pso.getFloorSwitch(switchId, floor)
+}
+
+func (s triggerState) WarpedIn() (bool, error) {
+	allPlayers, err := s.pso.getOtherPlayers()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range allPlayers {
+		if p.Location.Floor != 0 && !p.Location.Warping {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MonsterKilled only knows about monsters killed since the quest started
+func (s triggerState) MonsterKilled(unitxtId uint32) bool {
+	if !s.pso.GameState.QuestStarted {
+		return false
+	}
+	for _, monster := range s.pso.CurrentQuest.Monsters {
+		if monster.UnitxtId == unitxtId && !monster.Alive {
+			return true
+		}
 	}
+	return false
+}
+
+func (s triggerState) CurrentFloor() uint16 {
+	return s.pso.GameState.Floor
+}
+
+// WaveCleared is met once every monster that spawned since the current split started is dead. Monsters already
+// loaded when the quest started belong to no wave, and monsters spawning this tick belong to the next one.
+func (s triggerState) WaveCleared() bool {
+	questRun := s.pso.CurrentQuest
+	cleared := false
+	for _, monster := range questRun.Monsters {
+		if !monster.SpawnTime.After(questRun.QuestStartTime) || monster.SpawnTime.Before(s.pso.GameState.waveStart) ||
+			!monster.SpawnTime.Before(s.pso.tickTime) {
+			continue
+		}
+		if monster.Alive {
+			return false
+		}
+		cleared = true
+	}
+	return cleared
 }
 
 func (pso *PSO) getRngSeed() uint32 {
-	return numbers.ReadU32Unchecked(pso.handle, 0x00A9C22C)
+	return numbers.ReadU32Unchecked(pso.process, pso.offsets.Addresses.RngSeed)
 }
 
 func (pso *PSO) getPlayerCount() uint32 {
-	return numbers.ReadU32Unchecked(pso.handle, 0x00AAE168)
+	return numbers.ReadU32Unchecked(pso.process, pso.offsets.Addresses.PlayerCount)
 }
 
 func (pso *PSO) ephineaFastBurstEnabled() bool {
 	fastBurst := false
 	if pso.server == constants.EphineaServerName {
-		a := uintptr(numbers.ReadU32Unchecked(pso.handle, 0x5B92DA))
+		// relative jump target, measured from the end of the 5 byte instruction
+		jumpAddress := pso.offsets.Addresses.EphineaSlowBurst
+		a := uintptr(numbers.ReadU32Unchecked(pso.process, jumpAddress))
 		if a > 0 {
-			a += 0x5B92DF
-			slowBurstPtr := uintptr(numbers.ReadU32Unchecked(pso.handle, a))
+			a += jumpAddress + 5
+			slowBurstPtr := uintptr(numbers.ReadU32Unchecked(pso.process, a))
 			if slowBurstPtr > 0 {
-				fastBurst = numbers.ReadU16(pso.handle, slowBurstPtr) == 0
+				fastBurst = numbers.ReadU16(pso.process, slowBurstPtr) == 0
 			}
 		}
 	}
 	return fastBurst
 }
-
-func (pso *PSO) GetMonsterList() ([]Monster, error) {
-	npcArrayAddr := uintptr(numbers.ReadU32Unchecked(pso.handle, uintptr(0x007B4BA0+2)))
-	npcCount := int(numbers.ReadU32Unchecked(pso.handle, 0x00AAE164))
-	playerCount := int(pso.getPlayerCount())
-	ephineaMonsters := uintptr(numbers.ReadU32Unchecked(pso.handle, 0x00B5F800))
-
-	buf, _, ok := w32.ReadProcessMemory(pso.handle, npcArrayAddr, uintptr(4*(playerCount+npcCount+1)))
-	if !ok {
-		return nil, errors.New("unable to GetMonsterList")
-	}
-	monsterCount := 0
-	monsters := make([]Monster, 0)
-	for i := playerCount; i < (playerCount + npcCount); i++ {
-		monsterAddr := uintptr(numbers.Uint32FromU16(buf[2*i], buf[(2*i)+1]))
-		if monsterAddr != 0 {
-			monsterId := numbers.ReadU16(pso.handle, monsterAddr+0x1c)
-			untxtId, err := numbers.ReadU32(pso.handle, monsterAddr+0x378)
-			facing := numbers.ReadU16(pso.handle, monsterAddr+0x60)
-			if err != nil {
-				return nil, err
-			}
-			var hp uint16
-			lastAttackerIndex := numbers.ReadU16(pso.handle, monsterAddr+0x2D8)
-			if ephineaMonsters != 0 {
-				hp = numbers.ReadU16(pso.handle, ephineaMonsters+0x04+(uintptr(monsterId)*32))
-			} else {
-				hp = numbers.ReadU16(pso.handle, monsterAddr+0x334)
-			}
-			monsterStatus := numbers.ReadU16(pso.handle, monsterAddr+0x268)
-			frozen := monsterStatus == 0x02
-			confused := monsterStatus == 0x12
-			paralyzed := numbers.ReadU16(pso.handle, monsterAddr+0x25C) == 0x10
-			if untxtId == 45 {
-				// DRL
-				if i == 0 {
-					hp = numbers.ReadU16(pso.handle, monsterAddr+monsterDeRolLeHP)
-					// todo Missing skull hp atm
-				} else {
-					hp = numbers.ReadU16(pso.handle, monsterAddr+monsterDeRolLeShellHP)
-				}
-			} else if untxtId == 73 {
-				// Barba Ray
-				if i == 0 {
-					hp = numbers.ReadU16(pso.handle, monsterAddr+monsterBarbaRayHP)
-					// todo Missing skull hp atm
-				} else {
-					hp = numbers.ReadU16(pso.handle, monsterAddr+monsterBarbaRayShellHP)
-				}
-			}
-			// underflow seems to be possible
-			if hp > 0x8000 {
-				hp = 0
-			}
-			if untxtId != 0 {
-				monsterName, err := pso.getMonsterName(untxtId)
-				if err != nil {
-					log.Printf("cannot read monster name for id %v %v", untxtId, err)
-				} else {
-					monsterY := numbers.ReadF32(pso.handle, monsterAddr+0x3C)
-					if untxtId == 94 || untxtId == 95 {
-						// zu and pazuzu have weird height logic
-						monsterY += numbers.ReadF32(pso.handle, monsterAddr+0x418)
-					}
-					monsters = append(monsters, Monster{
-						Name:            monsterName,
-						hp:              hp,
-						Id:              monsterId,
-						Index:           i,
-						UnitxtId:        untxtId,
-						LastAttackerIdx: lastAttackerIndex,
-						Location: model.MonsterLocation{
-							HP:        hp,
-							Facing:    facing,
-							Frozen:    frozen,
-							Paralyzed: paralyzed,
-							Confused:  confused,
-							X:         numbers.ReadF32(pso.handle, monsterAddr+0x38),
-							Y:         monsterY,
-							Z:         numbers.ReadF32(pso.handle, monsterAddr+0x40),
-						},
-					})
-					if hp > 0 {
-						monsterCount++
-					}
-				}
-			}
-		}
-	}
-	pso.GameState.MonsterCount = monsterCount
-	return monsters, nil
-}
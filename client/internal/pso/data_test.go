@@ -0,0 +1,877 @@
+package pso
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+	"github.com/phelix-/psostats/v2/client/internal/pso/constants"
+	"github.com/phelix-/psostats/v2/client/internal/pso/quest"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+const (
+	testPlayerAddress  = uintptr(0x01000000)
+	testNpcArray       = uintptr(0x01100000)
+	testQuestPtr       = uintptr(0x01200000)
+	testQuestData      = uintptr(0x01210000)
+	testQuestRegisters = uintptr(0x01220000)
+	testUnitxt         = uintptr(0x01300000)
+	testItemArray      = uintptr(0x01500000)
+	testItems          = uintptr(0x01510000)
+	testItemSize       = uintptr(0x200)
+	testPmt            = uintptr(0x01600000)
+)
+
+type fakeProcess struct {
+	*numbers.FakeMemory
+}
+
+func (fakeProcess) Running() bool {
+	return true
+}
+
+func (fakeProcess) Close() {}
+
+// newSyntheticPso builds a memory image of one player standing in a game with no quest loaded
+func newSyntheticPso() (*PSO, *numbers.FakeMemory) {
+	memory := numbers.NewFakeMemory()
+	// game info block, also holds the player index and rng seed
+	memory.Zero(0x00A9B1C8, 0x00A9CD6A-0x00A9B1C8)
+	memory.WriteU16(0x00A9B1C8, 0) // episode 1
+	memory.WriteU16(0x00A9CD68, 3) // ultimate
+	memory.WriteU32(0x00A9C4F4, 0)
+	memory.WriteU32(0x00A9C22C, 1234)
+	memory.Zero(0x00AAFC98, 12)
+	memory.WriteU16(0x00AAFC9C, 1)
+	memory.WriteU16(0x00AAFCA0, 1)
+
+	memory.Zero(0x00A94254, 4*12)
+	memory.WriteU32(0x00A94254, uint32(testPlayerAddress))
+	memory.Zero(testPlayerAddress, 0x1000)
+	memory.WriteU16(testPlayerAddress+0x2BC, 2012)
+	memory.WriteU16(testPlayerAddress+0x334, 2012)
+	memory.WriteU16(testPlayerAddress+0x3F0, 1)
+	memory.WriteString(testPlayerAddress+0x428, "phelix")
+
+	// empty inventory
+	memory.WriteU32(0x00A8D81C, 0)
+	memory.WriteU32(0x00A8D820, 0)
+
+	// no monsters
+	memory.WriteU32(0x007B4BA0+2, uint32(testNpcArray))
+	memory.WriteU32(0x00AAE164, 0)
+	memory.WriteU32(0x00AAE168, 1)
+	memory.WriteU32(0x00B5F800, 0)
+	memory.Zero(testNpcArray, 0x400)
+	memory.WriteU32(0x00a9cd50, 0)
+
+	memory.WriteU32(0x00A95AA8, 0)
+	memory.Zero(0xAC9FA0, 32*18)
+
+	pso := New(make(chan QuestRun, 10), make(chan QuestRun, 10))
+	if err := pso.attach(fakeProcess{memory}, constants.UnseenServerName); err != nil {
+		panic(err)
+	}
+	pso.connected = true
+	return pso, memory
+}
+
+func loadQuest(memory *numbers.FakeMemory, number uint16, name string) {
+	memory.Zero(testQuestPtr, 0x200)
+	memory.WriteU32(testQuestPtr+0x19C, uint32(testQuestData))
+	memory.WriteU32(testQuestPtr+0x2C, uint32(testQuestRegisters))
+	memory.Zero(testQuestData, 0x100)
+	memory.WriteU16(testQuestData+0x10, number)
+	memory.WriteString(testQuestData+0x18, name)
+	memory.Zero(testQuestRegisters, 4*256)
+	memory.WriteU32(0x00A95AA8, uint32(testQuestPtr))
+}
+
+func setRegister(memory *numbers.FakeMemory, register uint16, value uint16) {
+	memory.WriteU16(testQuestRegisters+(4*uintptr(register)), value)
+}
+
+func refresh(pso *PSO, t *testing.T) {
+	if err := pso.RefreshData(); err != nil {
+		t.Fatalf("RefreshData: %v", err)
+	}
+}
+
+func TestRefreshData_NoQuest(t *testing.T) {
+	pso, _ := newSyntheticPso()
+	refresh(pso, t)
+
+	if pso.CurrentPlayerData.Name != "phelix" {
+		t.Errorf("Expected player 'phelix' but got '%v'", pso.CurrentPlayerData.Name)
+	}
+	if pso.GameState.Difficulty != "Ultimate" {
+		t.Errorf("Expected Ultimate but got '%v'", pso.GameState.Difficulty)
+	}
+	if !pso.GameState.AllowQuestStart {
+		t.Error("Quest start should be allowed once we've seen a game without a quest")
+	}
+	if pso.GameState.QuestStarted {
+		t.Error("Quest started without a quest loaded")
+	}
+}
+
+func TestRefreshData_QuestStartAndEnd(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+
+	refresh(pso, t)
+	if pso.GameState.QuestStarted {
+		t.Fatal("Quest started before register 0 was set")
+	}
+	if pso.GameState.QuestName != "Mop-up Operation #1" {
+		t.Errorf("Expected quest name 'Mop-up Operation #1' but got '%v'", pso.GameState.QuestName)
+	}
+
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	if !pso.GameState.QuestStarted {
+		t.Fatal("Quest did not start when register 0 was set")
+	}
+	started := <-pso.startedGame
+	if started.QuestName != "Mop-up Operation #1" || started.Difficulty != "Ultimate" {
+		t.Errorf("Unexpected started quest %v %v", started.QuestName, started.Difficulty)
+	}
+	if len(pso.CurrentQuest.HP) != 1 || pso.CurrentQuest.HP[0] != 2012 {
+		t.Errorf("Expected one recorded frame at 2012 HP but got %v", pso.CurrentQuest.HP)
+	}
+
+	setRegister(memory, 254, 1)
+	refresh(pso, t)
+	if !pso.GameState.QuestComplete {
+		t.Fatal("Quest did not complete when register 254 was set")
+	}
+	completed := <-pso.completeGame
+	if !completed.QuestComplete {
+		t.Error("Completed quest run was not marked complete")
+	}
+}
+
+func TestRefreshData_QuestEvents(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	events := make([]QuestEventType, 0)
+	pso.AddQuestEventListener(func(event QuestEvent) {
+		if event.QuestName != "Mop-up Operation #1" {
+			t.Errorf("Unexpected quest '%v' on %v event", event.QuestName, event.Type)
+		}
+		events = append(events, event.Type)
+	})
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	memory.WriteU16(testPlayerAddress+0x334, 0)
+	refresh(pso, t)
+	refresh(pso, t)
+	setRegister(memory, 254, 1)
+	refresh(pso, t)
+	<-pso.completeGame
+	refresh(pso, t)
+	memory.WriteU32(0x00A95AA8, 0)
+	refresh(pso, t)
+	refresh(pso, t)
+
+	expected := []QuestEventType{QuestStartEvent, DeathEvent, QuestCompleteEvent, ResetEvent}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v but got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected events %v but got %v", expected, events)
+		}
+	}
+}
+
+func TestRefreshData_DeltaEncodedDataFrames(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	pso.SetDataFrameRate(10)
+	start := time.Unix(1000, 0)
+	refreshAt := func(offset time.Duration) {
+		if err := pso.refreshDataAt(start.Add(offset)); err != nil {
+			t.Fatalf("refreshDataAt: %v", err)
+		}
+	}
+	refreshAt(0)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refreshAt(0)
+	<-pso.startedGame
+
+	for tick := 1; tick < 30; tick++ {
+		if tick == 14 {
+			memory.WriteU16(testPlayerAddress+0x334, 1000)
+		}
+		refreshAt(time.Duration(tick) * time.Second / 30)
+	}
+	run := pso.CurrentQuest
+	if len(run.DataFrames) != 0 || run.DataFrameRate != 10 {
+		t.Errorf("Expected no legacy frames at 10 frames a second but got %v", len(run.DataFrames))
+	}
+	frames := model.DecodeDataFrames(run.DataFrameDeltas)
+	if len(frames) != 10 {
+		t.Fatalf("Expected 10 frames in the first second but got %v", len(frames))
+	}
+	if frames[0].Millis() != 1000000 || frames[9].Millis() < 1000900 {
+		t.Errorf("Expected frames from 1000000ms to 1000900ms but got %v-%v", frames[0].Millis(), frames[9].Millis())
+	}
+	if frames[4].HP != 2012 || frames[5].HP != 1000 {
+		t.Errorf("Expected hp to drop to 1000 in the 6th frame but got %v %v", frames[4].HP, frames[5].HP)
+	}
+	if run.DataFrameDeltas[6].HP != nil || run.DataFrameDeltas[5].HP == nil {
+		t.Error("Expected hp only in the delta where it changed")
+	}
+	if latest, ok := run.LatestDataFrame(); !ok || latest.HP != 1000 {
+		t.Errorf("Expected the latest frame at 1000 hp but got %+v", latest)
+	}
+}
+
+func TestRefreshData_RngSeedChangeResetsQuest(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	resets := 0
+	pso.AddQuestEventListener(func(event QuestEvent) {
+		if event.Type == ResetEvent {
+			resets++
+		}
+	})
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	memory.WriteU32(0x00A9C22C, 4321)
+	refresh(pso, t)
+	if pso.GameState.QuestStarted {
+		t.Error("Quest should reset when the rng seed changes")
+	}
+	if resets != 1 {
+		t.Errorf("Expected one reset event but got %v", resets)
+	}
+}
+
+func TestCheckQuestStartConditions_FloorSwitch(t *testing.T) {
+	pso, _ := newSyntheticPso()
+	memory := pso.process.(fakeProcess).FakeMemory
+	config, _ := pso.questTypes.GetQuestConfig(960, 1, "August Atrocity #1")
+
+	started, err := pso.checkQuestStartConditions(config)
+	if err != nil || started {
+		t.Fatalf("Expected no start before switch 1 on floor 7, got %v %v", started, err)
+	}
+	// switch 1 is the second highest bit of the floor's first byte
+	memory.WriteU8(0xAC9FA0+(32*7), 0x40)
+	started, err = pso.checkQuestStartConditions(config)
+	if err != nil || !started {
+		t.Errorf("Expected start after switch 1 on floor 7, got %v %v", started, err)
+	}
+}
+
+func TestConsolidateFrame_Death(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	pso.CurrentPlayerData.HP = 0
+	pso.consolidateFrame(nil)
+	if pso.CurrentQuest.DeathCount != 1 {
+		t.Errorf("Expected 1 death but got %v", pso.CurrentQuest.DeathCount)
+	}
+	pso.consolidateFrame(nil)
+	if pso.CurrentQuest.DeathCount != 1 {
+		t.Errorf("Staying dead should not count as another death, got %v", pso.CurrentQuest.DeathCount)
+	}
+}
+
+func TestConsolidateMonsterState_DamageByPlayer(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	spawned := pso.tickTime
+	booma := func(hp uint16, attacker uint16, overkill uint16) []Monster {
+		return []Monster{{Name: "Booma", Id: 7, UnitxtId: 1, hp: hp, LastAttackerIdx: attacker, overkill: overkill}}
+	}
+	pso.consolidateMonsterState(booma(500, 0, 0))
+	pso.tickTime = spawned.Add(time.Second)
+	pso.consolidateMonsterState(booma(380, 1, 0))
+	pso.tickTime = spawned.Add(2 * time.Second)
+	pso.consolidateMonsterState(booma(300, 0, 0))
+	pso.tickTime = spawned.Add(2500 * time.Millisecond)
+	pso.consolidateMonsterState(booma(0, 1, 45))
+
+	monster := pso.CurrentQuest.Monsters[7]
+	if monster.Alive {
+		t.Fatal("Expected the monster to be dead")
+	}
+	if monster.MaxHp != 500 {
+		t.Errorf("Expected max hp 500 but got %v", monster.MaxHp)
+	}
+	if monster.DamageByPlayer[0] != 80 || monster.DamageByPlayer[1] != 420 {
+		t.Errorf("Expected 80 damage from player 0 and 420 from player 1 but got %v", monster.DamageByPlayer)
+	}
+	if monster.KilledBy != 1 {
+		t.Errorf("Expected player 1 to get the kill but got %v", monster.KilledBy)
+	}
+	if monster.TimeToKill != 2500*time.Millisecond {
+		t.Errorf("Expected a 2.5s kill but got %v", monster.TimeToKill)
+	}
+	if monster.Overkill != 45 {
+		t.Errorf("Expected 45 overkill but got %v", monster.Overkill)
+	}
+	if pso.CurrentQuest.PlayerDamage[1] != 420 || pso.CurrentQuest.LastHits[1] != 1 {
+		t.Errorf("Quest totals should still add up, got damage %v last hits %v",
+			pso.CurrentQuest.PlayerDamage, pso.CurrentQuest.LastHits)
+	}
+}
+
+func TestStartNewQuest_RecordsPartySlots(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	// a second player in slot 2 with slot 1 empty
+	secondPlayer := testPlayerAddress + 0x10000
+	memory.WriteU32(0x00A94254+4*2, uint32(secondPlayer))
+	memory.Zero(secondPlayer, 0x1000)
+	memory.WriteU16(secondPlayer+0x334, 1500)
+	memory.WriteString(secondPlayer+0x428, "ender")
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	players := pso.CurrentQuest.AllPlayers
+	if len(players) != 2 || players[0].Slot != 0 || players[1].Slot != 2 {
+		t.Fatalf("Expected players in slots 0 and 2 but got %+v", players)
+	}
+	pso.consolidateMonsterState([]Monster{{Name: "Booma", Id: 7, UnitxtId: 1, hp: 100}})
+	pso.tickTime = pso.tickTime.Add(time.Second)
+	pso.consolidateMonsterState([]Monster{{Name: "Booma", Id: 7, UnitxtId: 1, hp: 0, LastAttackerIdx: 2}})
+	killedBy := pso.CurrentQuest.Monsters[7].KilledBy
+	if player, found := pso.CurrentQuest.PlayerInSlot(killedBy); !found || player.Name != "ender" {
+		t.Errorf("Expected the kill to go to ender but got slot %v %+v", killedBy, player)
+	}
+	if _, found := pso.CurrentQuest.PlayerInSlot(1); found {
+		t.Error("Expected slot 1 to be empty")
+	}
+}
+
+func TestConsolidateMonsterState_StatusEffects(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	start := pso.tickTime
+	tick := func(millis int, freezeTraps uint16, location ...model.MonsterLocation) {
+		pso.tickTime = start.Add(time.Duration(millis) * time.Millisecond)
+		pso.CurrentPlayerData.FreezeTraps = freezeTraps
+		monsters := make([]Monster, len(location))
+		for i := range location {
+			monsters[i] = Monster{Name: "Booma", Id: uint16(i), UnitxtId: 1, hp: 100, Location: location[i]}
+		}
+		pso.consolidateMonsterState(monsters)
+	}
+	frozen := model.MonsterLocation{Frozen: true}
+	confused := model.MonsterLocation{Confused: true}
+	tick(0, 5, model.MonsterLocation{}, model.MonsterLocation{})
+	tick(1000, 4, model.MonsterLocation{}, model.MonsterLocation{})
+	tick(2000, 4, frozen, frozen)
+	tick(4000, 4, model.MonsterLocation{}, frozen)
+	// long after the trap, this freeze wasn't from it
+	tick(10000, 4, frozen, confused)
+	tick(11000, 4, model.MonsterLocation{}, model.MonsterLocation{})
+
+	first := pso.CurrentQuest.Monsters[0]
+	if first.Freezes != 2 || first.FrozenTime != 3*time.Second {
+		t.Errorf("Expected 2 freezes for 3s but got %v for %v", first.Freezes, first.FrozenTime)
+	}
+	second := pso.CurrentQuest.Monsters[1]
+	if second.Freezes != 1 || second.FrozenTime != 8*time.Second || second.Confusions != 1 || second.ConfusedTime != time.Second {
+		t.Errorf("Unexpected statuses on the second monster %+v", second)
+	}
+	effects := pso.CurrentQuest.StatusEffects
+	if effects.FreezeTrapsUsed != 1 || effects.FrozenByTraps != 2 || effects.Freezes != 3 || effects.FrozenTime != 11*time.Second {
+		t.Errorf("Unexpected quest status effects %+v", effects)
+	}
+	if len(pso.CurrentQuest.TrapUses) != 1 || pso.CurrentQuest.TrapUses[0].MonstersAffected != 2 {
+		t.Errorf("Expected the trap to catch 2 monsters but got %v", pso.CurrentQuest.TrapUses)
+	}
+}
+
+func TestConsolidateFrame_TechCasts(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	memory.WriteU16(testPlayerAddress+0x336, 100)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	memory.WriteU16(testPlayerAddress+0x348, 8)
+	memory.WriteU16(testPlayerAddress+0x464, 0x000D)
+	memory.WriteU16(testPlayerAddress+0x466, 14)
+	refresh(pso, t)
+	memory.WriteU16(testPlayerAddress+0x336, 80)
+	refresh(pso, t)
+	memory.WriteU16(testPlayerAddress+0x348, 1)
+	memory.WriteU16(testPlayerAddress+0x336, 70)
+	refresh(pso, t)
+	memory.WriteU16(testPlayerAddress+0x336, 60)
+	refresh(pso, t)
+
+	if len(pso.CurrentQuest.TechCasts) != 1 {
+		t.Fatalf("Expected one cast but got %v", pso.CurrentQuest.TechCasts)
+	}
+	cast := pso.CurrentQuest.TechCasts[0]
+	if cast.Tech != "Shifta" || cast.Level != 15 {
+		t.Errorf("Expected a level 15 Shifta but got level %v %v", cast.Level, cast.Tech)
+	}
+	if cast.TpStart != 100 || cast.TpSpent != 30 {
+		t.Errorf("Expected 30 of 100 TP spent but got %v of %v", cast.TpSpent, cast.TpStart)
+	}
+	if cast.Weapon != model.WeaponBareHanded {
+		t.Errorf("Expected the cast bare handed but got '%v'", cast.Weapon)
+	}
+	if pso.CurrentQuest.TechsCast["Shifta"] != 1 {
+		t.Errorf("Expected the cast counted in TechsCast but got %v", pso.CurrentQuest.TechsCast)
+	}
+}
+
+// setItems puts items owned by the player in the item array, each as type, group and index
+func setItems(memory *numbers.FakeMemory, codes ...[3]uint8) {
+	// an empty item table, so names come back unknown
+	memory.WriteU32(0x00A8DC94, uint32(testPmt))
+	memory.Zero(testPmt, 0x20)
+	memory.WriteU32(0x00A8D81C, uint32(testItemArray))
+	memory.WriteU32(0x00A8D820, uint32(len(codes)))
+	memory.Zero(testItemArray, 4*len(codes))
+	for i, code := range codes {
+		itemAddr := testItems + uintptr(i)*testItemSize
+		memory.WriteU32(testItemArray+uintptr(4*i), uint32(itemAddr))
+		memory.Zero(itemAddr, int(testItemSize))
+		memory.WriteU32(itemAddr+0xD8, uint32(0x00010000+i))
+		memory.WriteU8(itemAddr+0xF2, code[0])
+		memory.WriteU8(itemAddr+0xF3, code[1])
+		memory.WriteU8(itemAddr+0xF4, code[2])
+	}
+}
+
+func TestConsolidateFrame_ItemPickups(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	setItems(memory, [3]uint8{0, 0x01, 0x00})
+	refresh(pso, t)
+	<-pso.startedGame
+
+	memory.WriteU16(0x00AAFCA0, 2)
+	refresh(pso, t)
+	if len(pso.CurrentQuest.ItemPickups) != 0 {
+		t.Fatalf("Items held when the quest started aren't pickups, got %v", pso.CurrentQuest.ItemPickups)
+	}
+	setItems(memory, [3]uint8{0, 0x01, 0x00}, [3]uint8{0, 0x02, 0x01}, [3]uint8{1, 0x01, 0x20})
+	refresh(pso, t)
+	setItems(memory, [3]uint8{0, 0x01, 0x00}, [3]uint8{0, 0x02, 0x01})
+	refresh(pso, t)
+	setItems(memory, [3]uint8{0, 0x01, 0x00}, [3]uint8{0, 0x02, 0x01}, [3]uint8{1, 0x01, 0x20})
+	refresh(pso, t)
+
+	pickups := pso.CurrentQuest.ItemPickups
+	if len(pickups) != 2 {
+		t.Fatalf("Expected 2 pickups but got %v", pickups)
+	}
+	if pickups[0].Code != "000201" || pickups[0].Rare {
+		t.Errorf("Expected a common weapon first but got %v", pickups[0])
+	}
+	if pickups[1].Code != "010120" || !pickups[1].Rare {
+		t.Errorf("Expected a rare frame second but got %v", pickups[1])
+	}
+}
+
+func TestConsolidateFrame_PhotonBlasts(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	setItems(memory, [3]uint8{2, 0x05, 0x00})
+	memory.WriteU8(testItems+0x190, 0x01)
+	memory.WriteU8(testItems+0x1C8, 0x01)
+	memory.WriteU8(testItems+0x1C9, 0x02)
+	memory.WriteU8(testItems+0x1BE, 120)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	memory.WriteF32(testPlayerAddress+0x520, 100)
+	refresh(pso, t)
+	memory.WriteF32(testPlayerAddress+0x520, 0)
+	refresh(pso, t)
+	memory.WriteF32(testPlayerAddress+0x520, 40)
+	refresh(pso, t)
+
+	if len(pso.CurrentQuest.PhotonBlasts) != 1 {
+		t.Fatalf("Expected one blast but got %v", pso.CurrentQuest.PhotonBlasts)
+	}
+	photonBlast := pso.CurrentQuest.PhotonBlasts[0]
+	if photonBlast.Blast != "Golla" || photonBlast.Sync != 120 {
+		t.Errorf("Expected Golla from a mag at 120%% sync but got %v", photonBlast)
+	}
+	found := false
+	for _, event := range pso.CurrentQuest.Events {
+		found = found || event.Description == "Photon Blast: Golla"
+	}
+	if !found {
+		t.Errorf("Expected a photon blast event but got %v", pso.CurrentQuest.Events)
+	}
+}
+
+func TestConsolidateFrame_PhotonBlastFromMagWithSeveralBlasts(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	refresh(pso, t)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	setItems(memory, [3]uint8{2, 0x05, 0x00})
+	memory.WriteU8(testItems+0x190, 0x01)
+	memory.WriteU8(testItems+0x1C8, 0x03)
+	memory.WriteU8(testItems+0x1C9, 0x02|3<<3)
+	refresh(pso, t)
+	<-pso.startedGame
+
+	memory.WriteF32(testPlayerAddress+0x520, 100)
+	refresh(pso, t)
+	memory.WriteF32(testPlayerAddress+0x520, 0)
+	refresh(pso, t)
+	memory.WriteF32(testPlayerAddress+0x520, 40)
+	refresh(pso, t)
+
+	if len(pso.CurrentQuest.PhotonBlasts) != 1 {
+		t.Fatalf("Expected one blast but got %v", pso.CurrentQuest.PhotonBlasts)
+	}
+	photonBlast := pso.CurrentQuest.PhotonBlasts[0]
+	if photonBlast.Blast != "" || !reflect.DeepEqual(photonBlast.MagBlasts, []string{"Golla", "Pilla"}) {
+		t.Errorf("Expected no blast name and the mag's Golla and Pilla but got %v", photonBlast)
+	}
+	found := false
+	for _, event := range pso.CurrentQuest.Events {
+		found = found || event.Description == "Photon Blast (mag has Golla, Pilla)"
+	}
+	if !found {
+		t.Errorf("Expected a photon blast event naming the mag's blasts but got %v", pso.CurrentQuest.Events)
+	}
+}
+
+func TestCheckQuestConditions_Triggers(t *testing.T) {
+	u16 := func(value uint16) *uint16 { return &value }
+	u32 := func(value uint32) *uint32 { return &value }
+	tests := []struct {
+		name    string
+		trigger quest.Trigger
+		setup   func(pso *PSO, memory *numbers.FakeMemory)
+		met     bool
+	}{
+		{"register set", quest.Trigger{Register: u16(30)}, func(pso *PSO, memory *numbers.FakeMemory) {
+			setRegister(memory, 30, 1)
+		}, true},
+		{"register below threshold", quest.Trigger{Register: u16(51), Compare: ">=", Value: 300}, func(pso *PSO, memory *numbers.FakeMemory) {
+			setRegister(memory, 51, 299)
+		}, false},
+		{"register at threshold", quest.Trigger{Register: u16(51), Compare: ">=", Value: 300}, func(pso *PSO, memory *numbers.FakeMemory) {
+			setRegister(memory, 51, 300)
+		}, true},
+		{"entered floor", quest.Trigger{EnteredFloor: u16(11)}, func(pso *PSO, memory *numbers.FakeMemory) {
+			memory.WriteU16(0x00AAFCA0, 11)
+			refresh(pso, t)
+		}, true},
+		{"boss alive", quest.Trigger{Killed: u32(47)}, func(pso *PSO, memory *numbers.FakeMemory) {
+			pso.CurrentQuest.Monsters[1] = Monster{UnitxtId: 47, Alive: true}
+		}, false},
+		{"boss killed and register set", quest.Trigger{All: []quest.Trigger{{Killed: u32(47)}, {Register: u16(30)}}},
+			func(pso *PSO, memory *numbers.FakeMemory) {
+				pso.CurrentQuest.Monsters[1] = Monster{UnitxtId: 47, Alive: false}
+				setRegister(memory, 30, 1)
+			}, true},
+		{"any of floor or switch", quest.Trigger{Any: []quest.Trigger{{EnteredFloor: u16(11)}, {Floor: 7, Switch: 1}}},
+			func(pso *PSO, memory *numbers.FakeMemory) {
+				memory.WriteU8(0xAC9FA0+(32*7), 0x40)
+			}, true},
+		{"not warped in", quest.Trigger{Not: &quest.Trigger{WarpIn: true}}, func(pso *PSO, memory *numbers.FakeMemory) {
+		}, false},
+	}
+	for _, test := range tests {
+		pso, memory := newSyntheticPso()
+		refresh(pso, t)
+		loadQuest(memory, 101, "Mop-up Operation #1")
+		setRegister(memory, 0, 1)
+		refresh(pso, t)
+		<-pso.startedGame
+
+		test.setup(pso, memory)
+		config := quest.Quest{Name: test.name, Start: test.trigger, End: test.trigger}
+		started, err := pso.checkQuestStartConditions(config)
+		if err != nil || started != test.met {
+			t.Errorf("%v: expected start %v but got %v %v", test.name, test.met, started, err)
+		}
+		ended, err := pso.checkQuestEndConditions(config)
+		if err != nil || ended != test.met {
+			t.Errorf("%v: expected end %v but got %v %v", test.name, test.met, ended, err)
+		}
+	}
+}
+
+func TestCheckQuestEndConditions_Unset(t *testing.T) {
+	pso, _ := newSyntheticPso()
+	if _, err := pso.checkQuestEndConditions(quest.Quest{Name: "No End"}); err == nil {
+		t.Error("Expected an error for a quest without an end trigger")
+	}
+}
+
+func TestUpdateCurrentSplit_AllTriggerKinds(t *testing.T) {
+	u16 := func(value uint16) *uint16 { return &value }
+	pso, memory := newSyntheticPso()
+	start := time.Unix(1000, 0)
+	refreshAt := func(second int) {
+		if err := pso.refreshDataAt(start.Add(time.Duration(second) * time.Second)); err != nil {
+			t.Fatalf("refreshDataAt: %v", err)
+		}
+	}
+	refreshAt(0)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refreshAt(0)
+	<-pso.startedGame
+
+	config := quest.Quest{Splits: []quest.Split{
+		{Name: "Register", Trigger: quest.Trigger{Register: u16(50)}},
+		{Name: "Floor", Trigger: quest.Trigger{EnteredFloor: u16(2)}},
+		{Name: "Wave", Trigger: quest.Trigger{WaveCleared: true}},
+	}}
+	pso.CurrentQuest.Splits = make([]model.QuestRunSplit, len(config.Splits))
+	splitEvents := make([]string, 0)
+	pso.AddQuestEventListener(func(event QuestEvent) {
+		if event.Type == SplitEvent {
+			splitEvents = append(splitEvents, event.Split.Name)
+		}
+	})
+	pso.updateCurrentSplit(config)
+
+	setRegister(memory, 50, 1)
+	refreshAt(5)
+	pso.updateCurrentSplit(config)
+
+	memory.WriteU16(0x00AAFCA0, 2)
+	refreshAt(12)
+	pso.updateCurrentSplit(config)
+
+	refreshAt(15)
+	pso.updateCurrentSplit(config)
+	if !pso.CurrentQuest.Splits[2].End.IsZero() {
+		t.Fatal("Wave split ended before any monsters were seen")
+	}
+	addMonsters(memory, 2)
+	refreshAt(20)
+	pso.updateCurrentSplit(config)
+	memory.WriteU16(testMonsters+0x334, 0)
+	memory.WriteU16(testMonsters+testMonsterSize+0x334, 0)
+	refreshAt(31)
+	pso.updateCurrentSplit(config)
+
+	expectedEnds := []int{5, 12, 31}
+	for i, split := range pso.CurrentQuest.Splits {
+		if split.End.IsZero() || split.EndSecond != expectedEnds[i] {
+			t.Errorf("Expected split '%v' to end at second %v but got %v (%v)", split.Name, expectedEnds[i], split.EndSecond, split.End)
+		}
+	}
+	if len(splitEvents) != 3 || splitEvents[0] != "Register" || splitEvents[2] != "Wave" {
+		t.Errorf("Expected an event as each split ended but got %v", splitEvents)
+	}
+	if pso.CurrentQuest.Splits[2].StartSecond != 12 {
+		t.Errorf("Expected the wave split to start at second 12 but got %v", pso.CurrentQuest.Splits[2].StartSecond)
+	}
+}
+
+func TestUpdateCurrentSplit_WaveClearedIgnoresMonstersBeforeQuestStart(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	start := time.Unix(1000, 0)
+	refreshAt := func(second int) {
+		if err := pso.refreshDataAt(start.Add(time.Duration(second) * time.Second)); err != nil {
+			t.Fatalf("refreshDataAt: %v", err)
+		}
+	}
+	refreshAt(0)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	addMonsters(memory, 1)
+	refreshAt(1)
+	if pso.GameState.QuestStarted {
+		t.Fatal("Quest started before its start register was set")
+	}
+	memory.WriteU16(testMonsters+0x334, 0)
+	setRegister(memory, 0, 1)
+	refreshAt(2)
+	<-pso.startedGame
+
+	config := quest.Quest{Splits: []quest.Split{
+		{Name: "Wave", Trigger: quest.Trigger{WaveCleared: true}},
+	}}
+	pso.CurrentQuest.Splits = make([]model.QuestRunSplit, len(config.Splits))
+	pso.updateCurrentSplit(config)
+	refreshAt(3)
+	pso.updateCurrentSplit(config)
+	if !pso.CurrentQuest.Splits[0].End.IsZero() {
+		t.Error("Wave split ended from monsters seen before the quest started")
+	}
+}
+
+func TestUpdateCurrentSplit_WaveClearedOverlappingWaves(t *testing.T) {
+	u16 := func(value uint16) *uint16 { return &value }
+	pso, memory := newSyntheticPso()
+	start := time.Unix(1000, 0)
+	refreshAt := func(second int) {
+		if err := pso.refreshDataAt(start.Add(time.Duration(second) * time.Second)); err != nil {
+			t.Fatalf("refreshDataAt: %v", err)
+		}
+	}
+	refreshAt(0)
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	setRegister(memory, 0, 1)
+	refreshAt(0)
+	<-pso.startedGame
+
+	config := quest.Quest{Splits: []quest.Split{
+		{Name: "Register", Trigger: quest.Trigger{Register: u16(50)}},
+		{Name: "Wave", Trigger: quest.Trigger{WaveCleared: true}},
+	}}
+	pso.CurrentQuest.Splits = make([]model.QuestRunSplit, len(config.Splits))
+	pso.updateCurrentSplit(config)
+
+	// the first wave is still alive when the register ends the first split
+	addMonsters(memory, 1)
+	refreshAt(3)
+	pso.updateCurrentSplit(config)
+	setRegister(memory, 50, 1)
+	refreshAt(5)
+	pso.updateCurrentSplit(config)
+	refreshAt(6)
+	pso.updateCurrentSplit(config)
+	if !pso.CurrentQuest.Splits[1].End.IsZero() {
+		t.Fatal("Wave split ended before its wave spawned")
+	}
+
+	addMonsters(memory, 2)
+	refreshAt(8)
+	pso.updateCurrentSplit(config)
+	if !pso.CurrentQuest.Splits[1].End.IsZero() {
+		t.Fatal("Wave split ended while its wave was alive")
+	}
+	memory.WriteU16(testMonsters+testMonsterSize+0x334, 0)
+	refreshAt(12)
+	pso.updateCurrentSplit(config)
+	if split := pso.CurrentQuest.Splits[1]; split.End.IsZero() || split.EndSecond != 12 {
+		t.Errorf("Expected the wave split to end at second 12 with the first wave still alive but got %v", split)
+	}
+}
+
+// loadBoomaHunt loads a quest that ends, along with its last split, when a booma is killed
+func loadBoomaHunt(pso *PSO, memory *numbers.FakeMemory, t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quests.yaml")
+	definitions := `
+quests:
+  - episode: 1
+    name: Booma Hunt
+    number: 9001
+    start: {register: 0}
+    end: {killed: 9}
+    splits:
+      - {name: Start, trigger: {register: 1}}
+      - {name: Booma, trigger: {killed: 9}}
+`
+	if err := os.WriteFile(path, []byte(definitions), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := pso.LoadQuestDefinitions(path); len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+	loadQuest(memory, 9001, "Booma Hunt")
+}
+
+func TestRefreshData_KillEndsLastSplitAndQuest(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	start := time.Unix(1000, 0)
+	refreshAt := func(second int) {
+		if err := pso.refreshDataAt(start.Add(time.Duration(second) * time.Second)); err != nil {
+			t.Fatalf("refreshDataAt: %v", err)
+		}
+	}
+	refreshAt(0)
+	loadBoomaHunt(pso, memory, t)
+	addMonsters(memory, 1)
+	setRegister(memory, 0, 1)
+	refreshAt(1)
+	<-pso.startedGame
+	setRegister(memory, 1, 1)
+	refreshAt(2)
+
+	memory.WriteU16(testMonsters+0x334, 0)
+	refreshAt(5)
+	if !pso.GameState.QuestComplete {
+		t.Fatal("Quest didn't complete on the tick the booma was killed")
+	}
+	completed := <-pso.completeGame
+	if split := completed.Splits[1]; split.End.IsZero() || split.EndSecond != 4 {
+		t.Errorf("Expected the last split to end with the quest at second 4 but got %v", split)
+	}
+	pso.CurrentQuest.Splits[1] = model.QuestRunSplit{}
+	if completed.Splits[1].End.IsZero() {
+		t.Error("Completed run shares its splits with the quest still being read")
+	}
+}
+
+func TestRefreshData_LastSplitEventBeforeQuestComplete(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	events := make([]string, 0)
+	pso.AddQuestEventListener(func(event QuestEvent) {
+		description := string(event.Type)
+		if event.Split != nil {
+			description = description + " " + event.Split.Name
+		}
+		events = append(events, description)
+	})
+	refresh(pso, t)
+	loadBoomaHunt(pso, memory, t)
+	addMonsters(memory, 1)
+	setRegister(memory, 0, 1)
+	refresh(pso, t)
+	<-pso.startedGame
+	setRegister(memory, 1, 1)
+	refresh(pso, t)
+	memory.WriteU16(testMonsters+0x334, 0)
+	refresh(pso, t)
+	<-pso.completeGame
+
+	expected := []string{"questStart", "split Start", "split Booma", "questComplete"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v but got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected events %v but got %v", expected, events)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package pso
+
+import (
+	"time"
+
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+type QuestEventType string
+
+const (
+	QuestStartEvent    = QuestEventType("questStart")
+	SplitEvent         = QuestEventType("split")
+	DeathEvent         = QuestEventType("death")
+	QuestCompleteEvent = QuestEventType("questComplete")
+	// A started quest was cleared, either thrown away or left after completing it
+	ResetEvent = QuestEventType("reset")
+)
+
+// QuestEvent is a transition in the current quest run, sent to listeners as it's read from memory
+type QuestEvent struct {
+	Type      QuestEventType
+	Time      time.Time
+	QuestName string
+	// Category of the run
+	Players    int
+	PbCategory bool
+	// Seconds since the quest started
+	Second int
+	// The split that just ended on split events, the split reached on reset events
+	Split *model.QuestRunSplit `json:",omitempty"`
+}
+
+// QuestEventListener is called on the memory reading goroutine and must not block
+type QuestEventListener func(event QuestEvent)
+
+// AddQuestEventListener registers listener for quest events, call it before starting the connection
+func (pso *PSO) AddQuestEventListener(listener QuestEventListener) {
+	pso.eventListeners = append(pso.eventListeners, listener)
+}
+
+func (pso *PSO) sendQuestEvent(eventType QuestEventType, split *model.QuestRunSplit) {
+	event := QuestEvent{
+		Type:       eventType,
+		Time:       pso.tickTime,
+		QuestName:  pso.CurrentQuest.QuestName,
+		Players:    len(pso.CurrentQuest.AllPlayers),
+		PbCategory: pso.CurrentQuest.PbCategory,
+		Second:     int(pso.tickTime.Sub(pso.CurrentQuest.QuestStartTime).Seconds()),
+		Split:      split,
+	}
+	for _, listener := range pso.eventListeners {
+		listener(event)
+	}
+}
+
+// resetQuest clears the current quest, sending a reset event if one had started
+func (pso *PSO) resetQuest() {
+	if pso.GameState.QuestStarted {
+		var reached *model.QuestRunSplit
+		if len(pso.GameState.CurrentSplit.Name) > 0 {
+			currentSplit := pso.GameState.CurrentSplit
+			reached = &currentSplit
+		}
+		pso.sendQuestEvent(ResetEvent, reached)
+	}
+	pso.GameState.ClearQuest()
+}
@@ -6,55 +6,26 @@ import (
 	"github.com/phelix-/psostats/v2/pkg/model"
 	"log"
 
-	"github.com/TheTitanrain/w32"
 	"github.com/phelix-/psostats/v2/client/internal/numbers"
-)
-
-//goland:noinspection GoUnusedConst
-const (
-	itemArray          = 0x00A8D81C
-	itemArrayCount     = 0x00A8D820
-	itemId             = 0xD8
-	itemOwnerOffset    = 0xE4
-	itemCode           = 0xF2
-	itemEquippedOffset = 0x190
-	itemTypeOffset     = 0xF2
-	itemGroupOffset    = 0xF3
-	itemKills          = 0xE8
-	itemWepGrind       = 0x1F5
-	itemWepSpecial     = 0x1F6
-	itemWepStats       = 0x1C8
-	itemArmSlots       = 0x1B8
-	itemFrameDfp       = 0x1B9
-	itemFrameEvp       = 0x1BA
-	itemBarrierDfp     = 0x1E4
-	itemBarrierEvp     = 0x1E5
-	itemUnitMod        = 0x1DC
-	itemMagStats       = 0x1C0
-	itemMagPBHas       = 0x1C8
-	itemMagPB          = 0x1C9
-	itemMagColor       = 0x1CA
-	itemMagSync        = 0x1BE
-	itemMagIQ          = 0x1BC
-	itemMagTimer       = 0x1B4
-	itemToolCount      = 0x104
-	itemTechType       = 0x108
-	itemMesetaAmount   = 0x100
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
 )
 
 type Inventory struct {
 	Equipment      []Equipment
+	Items          []Item
 	EquippedWeapon Equipment
-	Monomate       uint8
-	Dimate         uint8
-	Trimate        uint8
-	Monofluid      uint8
-	Difluid        uint8
-	Trifluid       uint8
-	MoonAtomizer   uint8
-	StarAtomizer   uint8
-	SolAtomizer    uint8
-	Telepipe       uint8
+	// Nil when no mag is equipped
+	EquippedMag  *Mag
+	Monomate     uint8
+	Dimate       uint8
+	Trimate      uint8
+	Monofluid    uint8
+	Difluid      uint8
+	Trifluid     uint8
+	MoonAtomizer uint8
+	StarAtomizer uint8
+	SolAtomizer  uint8
+	Telepipe     uint8
 }
 
 type Equipment struct {
@@ -64,27 +35,28 @@ type Equipment struct {
 	Display     string
 }
 
-func ReadInventory(handle w32.HANDLE, playerIndex uint8) (Inventory, error) {
+func ReadInventory(memory numbers.MemoryReader, profile offsets.Profile, playerIndex uint8) (Inventory, error) {
 	inventory := Inventory{}
 	equipment := make([]Equipment, 0)
+	items := make([]Item, 0)
 	equippedWeapon := Equipment{
 		Id:          model.WeaponBareHanded,
 		UnitxtIndex: "",
 		Type:        model.EquipmentTypeWeapon,
 		Display:     model.WeaponBareHanded,
 	}
-	buf, _, ok := w32.ReadProcessMemory(handle, uintptr(itemArrayCount), 2)
+	buf, ok := memory.ReadMemory(profile.Addresses.ItemArrayCount, 2)
 	if !ok {
 		return inventory, errors.New("could not read item count")
 	}
 	count := numbers.Uint32From16(buf[0:2])
-	buf, _, ok = w32.ReadProcessMemory(handle, uintptr(itemArray), 4)
+	buf, ok = memory.ReadMemory(profile.Addresses.ItemArray, 4)
 	if !ok {
 		return inventory, errors.New("could not read item array")
 	}
 	address := numbers.Uint32From16(buf[0:2])
 	if count != 0 && address != 0 {
-		buf, _, ok = w32.ReadProcessMemory(handle, uintptr(address), uintptr(4*count))
+		buf, ok = memory.ReadMemory(uintptr(address), uintptr(4*count))
 		if !ok {
 			return inventory, errors.New("could not read item array")
 		}
@@ -92,16 +64,29 @@ func ReadInventory(handle w32.HANDLE, playerIndex uint8) (Inventory, error) {
 		for i := 0; i < int(count); i++ {
 			itemAddr := numbers.Uint32From16(buf[i*2 : (i*2)+2])
 			if itemAddr != 0 {
-				itemBuffer, _, ok := w32.ReadProcessMemory(handle, uintptr(itemAddr+0xD8), 4)
+				itemBuffer, ok := memory.ReadMemory(uintptr(itemAddr)+profile.Item.Id, 4)
 				if !ok {
 					return inventory, errors.New("could not read item")
 				}
 				itemId := fmt.Sprintf("%04x%04x", itemBuffer[1], itemBuffer[0])
-				itemType := numbers.ReadU8(handle, uintptr(itemAddr+itemTypeOffset))
-				itemGroup := numbers.ReadU8(handle, uintptr(itemAddr+itemGroupOffset))
-				indexInGroup := numbers.ReadU8(handle, uintptr(itemAddr+0xF4))
-				equipped := numbers.ReadU8(handle, uintptr(itemAddr+itemEquippedOffset))&0x01 == 1
-				itemOwner := numbers.ReadU8(handle, uintptr(itemAddr+itemOwnerOffset))
+				itemType := numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.Type)
+				itemGroup := numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.Group)
+				indexInGroup := numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.Index)
+				equipped := numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.Equipped)&0x01 == 1
+				itemOwner := numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.Owner)
+				if itemOwner == playerIndex {
+					item := Item{
+						Id:      itemId,
+						Type:    itemType,
+						Group:   itemGroup,
+						Index:   indexInGroup,
+						address: uintptr(itemAddr),
+					}
+					if itemType == 3 {
+						item.Count = readToolCount(memory, profile, uintptr(itemAddr))
+					}
+					items = append(items, item)
+				}
 				if itemOwner == playerIndex && equipped {
 					currentEquipment := Equipment{
 						Id:          itemId,
@@ -109,7 +94,7 @@ func ReadInventory(handle w32.HANDLE, playerIndex uint8) (Inventory, error) {
 					}
 					switch itemType {
 					case 0:
-						weapon := readWeapon(handle, int(itemAddr), itemId, itemGroup, indexInGroup)
+						weapon := readWeapon(memory, profile, int(itemAddr), itemId, itemGroup, indexInGroup)
 						currentEquipment.Type = model.EquipmentTypeWeapon
 						currentEquipment.Display = weapon.String()
 						equipment = append(equipment, currentEquipment)
@@ -117,30 +102,30 @@ func ReadInventory(handle w32.HANDLE, playerIndex uint8) (Inventory, error) {
 					case 1:
 						switch itemGroup {
 						case 1:
-							frame := readFrame(handle, int(itemAddr), itemId, itemGroup, indexInGroup)
+							frame := readFrame(memory, profile, int(itemAddr), itemId, itemGroup, indexInGroup)
 							currentEquipment.Type = model.EquipmentTypeFrame
 							currentEquipment.Display = frame.String()
 							equipment = append(equipment, currentEquipment)
 						case 2:
-							barrier := readBarrier(handle, int(itemAddr), itemId, itemGroup, indexInGroup)
+							barrier := readBarrier(memory, profile, int(itemAddr), itemId, itemGroup, indexInGroup)
 							currentEquipment.Type = model.EquipmentTypeBarrier
 							currentEquipment.Display = barrier.StringNoSlots()
 							equipment = append(equipment, currentEquipment)
 						case 3:
-							unit := readUnit(handle, int(itemAddr), indexInGroup, itemId)
+							unit := readUnit(memory, profile, int(itemAddr), indexInGroup, itemId)
 							currentEquipment.Type = model.EquipmentTypeUnit
 							currentEquipment.Display = unit.Name
 							equipment = append(equipment, currentEquipment)
 						}
 					case 2:
-						mag := readMag(handle, int(itemAddr), itemId, itemGroup)
+						mag := readMag(memory, profile, int(itemAddr), itemId, itemGroup)
 						currentEquipment.Type = model.EquipmentTypeMag
 						currentEquipment.Display = mag.String()
 						equipment = append(equipment, currentEquipment)
+						inventory.EquippedMag = &mag
 					}
 				} else if itemType == 3 {
-					count := numbers.ReadU8(handle, uintptr(itemAddr+itemToolCount))
-					count = count ^ uint8(itemAddr+itemToolCount)
+					count := readToolCount(memory, profile, uintptr(itemAddr))
 					addConsumableToInventory(&inventory, itemGroup, indexInGroup, count)
 
 				}
@@ -149,55 +134,62 @@ func ReadInventory(handle w32.HANDLE, playerIndex uint8) (Inventory, error) {
 	}
 
 	inventory.Equipment = equipment
+	inventory.Items = items
 	inventory.EquippedWeapon = equippedWeapon
 	return inventory, nil
 }
 
-func getWeaponIndex(handle w32.HANDLE, group uint8, index uint8, typeOffset uint8, sizeSomething uint32) uint32 {
+// readToolCount is the size of a tool stack, which is kept xor'd with the low byte of its address
+func readToolCount(memory numbers.MemoryReader, profile offsets.Profile, itemAddr uintptr) uint8 {
+	count := numbers.ReadU8(memory, itemAddr+profile.Item.ToolCount)
+	return count ^ uint8(itemAddr+profile.Item.ToolCount)
+}
+
+func getWeaponIndex(memory numbers.MemoryReader, profile offsets.Profile, group uint8, index uint8, typeOffset uint8, sizeSomething uint32) uint32 {
 	weaponIndex := uint32(0)
-	pmtAddress := numbers.ReadU32Unchecked(handle, 0x00a8dc94)
-	weaponAddress := numbers.ReadU32Unchecked(handle, uintptr(pmtAddress+uint32(typeOffset)))
+	pmtAddress := numbers.ReadU32Unchecked(memory, profile.Addresses.PmtPointer)
+	weaponAddress := numbers.ReadU32Unchecked(memory, uintptr(pmtAddress+uint32(typeOffset)))
 	if weaponAddress != 0 {
 		groupAddress := weaponAddress + (uint32(group) * 8)
-		itemAddress := numbers.ReadU32Unchecked(handle, uintptr(groupAddress+4)) + (sizeSomething * uint32(index))
-		weaponIndex = numbers.ReadU32Unchecked(handle, uintptr(itemAddress))
+		itemAddress := numbers.ReadU32Unchecked(memory, uintptr(groupAddress+4)) + (sizeSomething * uint32(index))
+		weaponIndex = numbers.ReadU32Unchecked(memory, uintptr(itemAddress))
 	}
 	return weaponIndex
 }
 
-func readItemName(handle w32.HANDLE, index int) string {
-	unitxtPointer := numbers.ReadU32Unchecked(handle, 0x00a9cd50)
+func readItemName(memory numbers.MemoryReader, profile offsets.Profile, index int) string {
+	unitxtPointer := numbers.ReadU32Unchecked(memory, profile.Addresses.UnitxtPointer)
 	if unitxtPointer == 0 {
 		return "?"
 	}
-	weaponIndex := numbers.ReadU32Unchecked(handle, uintptr(unitxtPointer+4))
-	weaponNameAddress := numbers.ReadU32Unchecked(handle, uintptr(weaponIndex+uint32(4*index)))
+	weaponIndex := numbers.ReadU32Unchecked(memory, uintptr(unitxtPointer+4))
+	weaponNameAddress := numbers.ReadU32Unchecked(memory, uintptr(weaponIndex+uint32(4*index)))
 
-	weaponName, err := numbers.ReadNullTerminatedString(handle, uintptr(weaponNameAddress))
+	weaponName, err := numbers.ReadNullTerminatedString(memory, uintptr(weaponNameAddress))
 	if err != nil {
 		log.Fatalf("Error getting weapon name %v", err)
 	}
 	return fmt.Sprintf("%v", weaponName)
 }
 
-func readWeapon(handle w32.HANDLE, itemAddr int, itemId string, itemGroup, itemIndex uint8) Weapon {
-	weaponIndex := getWeaponIndex(handle, itemGroup, itemIndex, 0x00, 44)
+func readWeapon(memory numbers.MemoryReader, profile offsets.Profile, itemAddr int, itemId string, itemGroup, itemIndex uint8) Weapon {
+	weaponIndex := getWeaponIndex(memory, profile, itemGroup, itemIndex, 0x00, 44)
 	weapon := Weapon{
 		Id: itemId,
 	}
-	weapon.Name = readItemName(handle, int(weaponIndex))
-	weapon.Grind = numbers.ReadU8(handle, uintptr(itemAddr+itemWepGrind))
+	weapon.Name = readItemName(memory, profile, int(weaponIndex))
+	weapon.Grind = numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.WepGrind)
 	isSRank := (itemGroup >= 0x70 && itemGroup < 0x89) || (itemGroup >= 0xA5 && itemGroup < 0xAA)
 	if isSRank {
 		weapon.Special = itemIndex
 		weapon.SpecialName = getSRankSpecial(weapon.Special)
 	} else {
-		weapon.Special = numbers.ReadU8(handle, uintptr(itemAddr+itemWepSpecial))
+		weapon.Special = numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.WepSpecial)
 		weapon.SpecialName = getWeaponSpecial(weapon.Special)
 	}
 	for j := 0; j < 6; j += 2 {
-		area := numbers.ReadU8(handle, uintptr(itemAddr+itemWepStats+j))
-		percent := numbers.ReadI8(handle, uintptr(itemAddr+itemWepStats+j+1))
+		area := numbers.ReadU8(memory, uintptr(itemAddr+j)+profile.Item.WepStats)
+		percent := numbers.ReadI8(memory, uintptr(itemAddr+j+1)+profile.Item.WepStats)
 		switch area {
 		case 1:
 			weapon.Native = percent
@@ -239,14 +231,14 @@ func (w Weapon) String() string {
 	return fmt.Sprintf("%v%v%v [%v/%v/%v/%v|%v]", w.Name, grindString, specialString, w.Native, w.ABeast, w.Machine, w.Dark, w.Hit)
 }
 
-func readFrame(handle w32.HANDLE, itemAddr int, itemId string, itemGroup, itemIndex uint8) Frame {
-	weaponIndex := getWeaponIndex(handle, itemGroup-1, itemIndex, 0x04, 32)
+func readFrame(memory numbers.MemoryReader, profile offsets.Profile, itemAddr int, itemId string, itemGroup, itemIndex uint8) Frame {
+	weaponIndex := getWeaponIndex(memory, profile, itemGroup-1, itemIndex, 0x04, 32)
 	weapon := Frame{
 		Id:    itemId,
-		Name:  readItemName(handle, int(weaponIndex)),
-		Dfp:   numbers.ReadU8(handle, uintptr(itemAddr)+itemFrameDfp),
-		Evp:   numbers.ReadU8(handle, uintptr(itemAddr)+itemFrameEvp),
-		Slots: numbers.ReadU8(handle, uintptr(itemAddr)+itemArmSlots),
+		Name:  readItemName(memory, profile, int(weaponIndex)),
+		Dfp:   numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.FrameDfp),
+		Evp:   numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.FrameEvp),
+		Slots: numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.ArmSlots),
 	}
 	return weapon
 }
@@ -268,35 +260,39 @@ func (f Frame) String() string {
 	return fmt.Sprintf("%v [%v|%v] [%vs]", f.Name, f.Dfp, f.Evp, f.Slots)
 }
 
-func readBarrier(handle w32.HANDLE, itemAddr int, itemId string, itemGroup, itemIndex uint8) Frame {
-	weaponIndex := getWeaponIndex(handle, itemGroup-1, itemIndex, 0x04, 32)
+func readBarrier(memory numbers.MemoryReader, profile offsets.Profile, itemAddr int, itemId string, itemGroup, itemIndex uint8) Frame {
+	weaponIndex := getWeaponIndex(memory, profile, itemGroup-1, itemIndex, 0x04, 32)
 	weapon := Frame{
 		Id:   itemId,
-		Name: readItemName(handle, int(weaponIndex)),
-		Dfp:  numbers.ReadU8(handle, uintptr(itemAddr+itemBarrierDfp)),
-		Evp:  numbers.ReadU8(handle, uintptr(itemAddr+itemBarrierEvp)),
+		Name: readItemName(memory, profile, int(weaponIndex)),
+		Dfp:  numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.BarrierDfp),
+		Evp:  numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.BarrierEvp),
 	}
 	return weapon
 }
 
-func readUnit(handle w32.HANDLE, itemAddr int, itemIndex uint8, itemId string) Frame {
-	weaponIndex := getWeaponIndex(handle, 0, itemIndex, 0x08, 20)
+func readUnit(memory numbers.MemoryReader, profile offsets.Profile, itemAddr int, itemIndex uint8, itemId string) Frame {
+	weaponIndex := getWeaponIndex(memory, profile, 0, itemIndex, 0x08, 20)
 	weapon := Frame{
 		Id:   itemId,
-		Name: readItemName(handle, int(weaponIndex)),
+		Name: readItemName(memory, profile, int(weaponIndex)),
 	}
 	return weapon
 }
 
-func readMag(handle w32.HANDLE, itemAddr int, itemId string, itemGroup uint8) Mag {
-	weaponIndex := getWeaponIndex(handle, 0, itemGroup, 0x10, 28)
+func readMag(memory numbers.MemoryReader, profile offsets.Profile, itemAddr int, itemId string, itemGroup uint8) Mag {
+	weaponIndex := getWeaponIndex(memory, profile, 0, itemGroup, 0x10, 28)
 	return Mag{
 		Id:   itemId,
-		Name: readItemName(handle, int(weaponIndex)),
-		Def:  (int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+1)))<<8 + int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+0)))) / 100,
-		Pow:  (int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+3)))<<8 + int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+2)))) / 100,
-		Dex:  (int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+5)))<<8 + int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+4)))) / 100,
-		Mind: (int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+7)))<<8 + int(numbers.ReadU8(handle, uintptr(itemAddr+itemMagStats+6)))) / 100,
+		Name: readItemName(memory, profile, int(weaponIndex)),
+		PhotonBlasts: decodePhotonBlasts(numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.MagPBHas),
+			numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.MagPB)),
+		Sync: numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.MagSync),
+		IQ:   numbers.ReadU8(memory, uintptr(itemAddr)+profile.Item.MagIQ),
+		Def:  (int(numbers.ReadU8(memory, uintptr(itemAddr+1)+profile.Item.MagStats))<<8 + int(numbers.ReadU8(memory, uintptr(itemAddr+0)+profile.Item.MagStats))) / 100,
+		Pow:  (int(numbers.ReadU8(memory, uintptr(itemAddr+3)+profile.Item.MagStats))<<8 + int(numbers.ReadU8(memory, uintptr(itemAddr+2)+profile.Item.MagStats))) / 100,
+		Dex:  (int(numbers.ReadU8(memory, uintptr(itemAddr+5)+profile.Item.MagStats))<<8 + int(numbers.ReadU8(memory, uintptr(itemAddr+4)+profile.Item.MagStats))) / 100,
+		Mind: (int(numbers.ReadU8(memory, uintptr(itemAddr+7)+profile.Item.MagStats))<<8 + int(numbers.ReadU8(memory, uintptr(itemAddr+6)+profile.Item.MagStats))) / 100,
 	}
 }
 
@@ -307,6 +303,52 @@ type Mag struct {
 	Pow  int
 	Dex  int
 	Mind int
+	// Blasts in the center, right and left slots, only the filled slots are included
+	PhotonBlasts []string
+	Sync         uint8
+	IQ           uint8
+}
+
+var photonBlastNames = []string{"Farlla", "Estlla", "Golla", "Pilla", "Leilla", "Mylla & Youlla"}
+
+// decodePhotonBlasts reads a mag's blasts. has flags which slots are filled, pb holds the center blast in the low 3
+// bits and the right in the next 3. The left blast is the last 2 bits, as an index into the blasts not in the other
+// two slots.
+func decodePhotonBlasts(has uint8, pb uint8) []string {
+	blasts := make([]string, 0, 3)
+	center, right := -1, -1
+	if has&0x01 != 0 && int(pb&0x07) < len(photonBlastNames) {
+		center = int(pb & 0x07)
+		blasts = append(blasts, photonBlastNames[center])
+	}
+	if has&0x02 != 0 && int((pb>>3)&0x07) < len(photonBlastNames) {
+		right = int((pb >> 3) & 0x07)
+		blasts = append(blasts, photonBlastNames[right])
+	}
+	if has&0x04 != 0 {
+		left := int(pb >> 6)
+		for i := range photonBlastNames {
+			if i == center || i == right {
+				continue
+			}
+			if left == 0 {
+				blasts = append(blasts, photonBlastNames[i])
+				break
+			}
+			left--
+		}
+	}
+	return blasts
+}
+
+// PhotonBlast is the blast the mag fired, when there's only one it could have been. Which blast was picked from the
+// action palette isn't read from memory, so it's empty for a mag with more than one blast and PhotonBlasts are the
+// blasts it could have been.
+func (m Mag) PhotonBlast() string {
+	if len(m.PhotonBlasts) != 1 {
+		return ""
+	}
+	return m.PhotonBlasts[0]
 }
 
 func (m Mag) String() string {
@@ -0,0 +1,137 @@
+package inventory
+
+import (
+	"fmt"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// Item is anything in the player's inventory. Only the item code is read every tick, the name and stats are read by
+// DescribeItem once an item turns out to be new.
+type Item struct {
+	Id    string
+	Type  uint8
+	Group uint8
+	Index uint8
+	// Size of the stack for tools
+	Count   uint8
+	address uintptr
+}
+
+// Code is the item's type, group and index in hex, the same as Equipment.UnitxtIndex
+func (item Item) Code() string {
+	return fmt.Sprintf("%02x%02x%02x", item.Type, item.Group, item.Index)
+}
+
+// rareUnits are the unit indexes that only drop as rares: the God units, the top resist, revival, PB and battle units,
+// and everything from Yasakani Magatama on. The rest of the units are common drops.
+var rareUnits = map[uint8]bool{
+	0x03: true, // God/Power
+	0x07: true, // God/Mind
+	0x0B: true, // God/Arm
+	0x0F: true, // God/Legs
+	0x13: true, // God/HP
+	0x17: true, // God/TP
+	0x1B: true, // God/Body
+	0x1D: true, // God/Luck
+	0x20: true, // God/Ability
+	0x32: true, // Perfect/Resist
+	0x35: true, // HP/Revival
+	0x38: true, // TP/Revival
+	0x3B: true, // PB/Create
+	0x3E: true, // God/Technique
+	0x41: true, // God/Battle
+}
+
+// IsRare is whether the item's code is one that only drops as a rare: weapons, frames and barriers outside the common
+// ranges, the rare units, and photon drops, spheres and crystals. Mags aren't flagged since a picked up mag is just as
+// likely to be someone's fed mag as a drop.
+func (item Item) IsRare() bool {
+	switch item.Type {
+	case 0:
+		return item.Group > 0x0C || item.Index > 0x04
+	case 1:
+		switch item.Group {
+		case 1:
+			return item.Index > 0x17
+		case 2:
+			return item.Index > 0x14
+		case 3:
+			return item.Index >= 0x48 || rareUnits[item.Index]
+		}
+	case 3:
+		return item.Group == 0x10
+	}
+	return false
+}
+
+// PickedUp is every item that's in the inventory but wasn't in previous, along with tool stacks that grew. Count is
+// how many were added to the stack.
+func (inventory Inventory) PickedUp(previous Inventory) []Item {
+	previousItems := make(map[string]Item)
+	for _, item := range previous.Items {
+		previousItems[item.Id] = item
+	}
+	pickedUp := make([]Item, 0)
+	for _, item := range inventory.Items {
+		previousItem, found := previousItems[item.Id]
+		if !found {
+			pickedUp = append(pickedUp, item)
+		} else if item.Type == 3 && item.Count > previousItem.Count {
+			item.Count -= previousItem.Count
+			pickedUp = append(pickedUp, item)
+		}
+	}
+	return pickedUp
+}
+
+// DescribeItem reads the name and stats of an item read this tick
+func DescribeItem(memory numbers.MemoryReader, profile offsets.Profile, item Item) model.ItemPickup {
+	pickup := model.ItemPickup{
+		Code:  item.Code(),
+		Count: item.Count,
+		Rare:  item.IsRare(),
+	}
+	itemAddr := int(item.address)
+	switch item.Type {
+	case 0:
+		weapon := readWeapon(memory, profile, itemAddr, item.Id, item.Group, item.Index)
+		pickup.Name = weapon.Name
+		pickup.Grind = weapon.Grind
+		pickup.Special = weapon.SpecialName
+		pickup.Display = weapon.String()
+	case 1:
+		switch item.Group {
+		case 1:
+			frame := readFrame(memory, profile, itemAddr, item.Id, item.Group, item.Index)
+			pickup.Name = frame.Name
+			pickup.Display = frame.String()
+		case 2:
+			barrier := readBarrier(memory, profile, itemAddr, item.Id, item.Group, item.Index)
+			pickup.Name = barrier.Name
+			pickup.Display = barrier.StringNoSlots()
+		case 3:
+			unit := readUnit(memory, profile, itemAddr, item.Index, item.Id)
+			pickup.Name = unit.Name
+			pickup.Display = unit.Name
+		}
+	case 2:
+		mag := readMag(memory, profile, itemAddr, item.Id, item.Group)
+		pickup.Name = mag.Name
+		pickup.Display = mag.String()
+	case 3:
+		toolIndex := getWeaponIndex(memory, profile, item.Group, item.Index, 0x0C, 24)
+		pickup.Name = readItemName(memory, profile, int(toolIndex))
+		pickup.Display = pickup.Name
+		if item.Count > 1 {
+			pickup.Display = fmt.Sprintf("%v x%d", pickup.Name, item.Count)
+		}
+	}
+	if len(pickup.Name) == 0 || pickup.Name == "?" {
+		pickup.Name = "?"
+		pickup.Display = fmt.Sprintf("? (%v)", pickup.Code)
+	}
+	return pickup
+}
@@ -0,0 +1,93 @@
+package inventory
+
+import "testing"
+
+func TestItem_IsRare(t *testing.T) {
+	tests := []struct {
+		name string
+		item Item
+		rare bool
+	}{
+		{"Saber", Item{Type: 0, Group: 0x01, Index: 0x00}, false},
+		{"Flowen's Sword", Item{Type: 0, Group: 0x01, Index: 0x05}, true},
+		{"Spread Needle", Item{Type: 0, Group: 0x0D, Index: 0x00}, true},
+		{"Celestial Armor", Item{Type: 1, Group: 0x01, Index: 0x17}, false},
+		{"Hunter Field", Item{Type: 1, Group: 0x01, Index: 0x18}, true},
+		{"Celestial Shield", Item{Type: 1, Group: 0x02, Index: 0x14}, false},
+		{"Invisible Guard", Item{Type: 1, Group: 0x02, Index: 0x15}, true},
+		{"Knight/Power", Item{Type: 1, Group: 0x03, Index: 0x00}, false},
+		{"God/Power", Item{Type: 1, Group: 0x03, Index: 0x03}, true},
+		{"Cure/Shock", Item{Type: 1, Group: 0x03, Index: 0x47}, false},
+		{"Yasakani Magatama", Item{Type: 1, Group: 0x03, Index: 0x48}, true},
+		{"Mag", Item{Type: 2, Group: 0x00}, false},
+		{"Fed mag", Item{Type: 2, Group: 0x06}, false},
+		{"Trimate", Item{Type: 3, Group: 0x00, Index: 0x02}, false},
+		{"Cell of MAG 502", Item{Type: 3, Group: 0x0C, Index: 0x00}, false},
+		{"Photon Drop", Item{Type: 3, Group: 0x10, Index: 0x00}, true},
+		{"Photon Crystal", Item{Type: 3, Group: 0x10, Index: 0x02}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.IsRare(); got != tt.rare {
+				t.Errorf("IsRare() = %v, want %v", got, tt.rare)
+			}
+		})
+	}
+}
+
+func TestInventory_PickedUp(t *testing.T) {
+	previous := Inventory{Items: []Item{
+		{Id: "a", Type: 0},
+		{Id: "b", Type: 3, Count: 4},
+	}}
+	current := Inventory{Items: []Item{
+		{Id: "a", Type: 0},
+		{Id: "b", Type: 3, Count: 6},
+		{Id: "c", Type: 1, Group: 1},
+	}}
+
+	pickedUp := current.PickedUp(previous)
+	if len(pickedUp) != 2 {
+		t.Fatalf("Expected the new item and the bigger stack but got %v", pickedUp)
+	}
+	if pickedUp[0].Id != "b" || pickedUp[0].Count != 2 {
+		t.Errorf("Expected 2 more of b but got %v", pickedUp[0])
+	}
+	if pickedUp[1].Id != "c" {
+		t.Errorf("Expected c to be picked up but got %v", pickedUp[1])
+	}
+	if len(previous.PickedUp(current)) != 0 {
+		t.Errorf("Items leaving the inventory aren't pickups")
+	}
+}
+
+func TestDecodePhotonBlasts(t *testing.T) {
+	tests := []struct {
+		name   string
+		has    uint8
+		pb     uint8
+		blasts []string
+	}{
+		{"none", 0x00, 0x00, []string{}},
+		{"center only", 0x01, 0x02, []string{"Golla"}},
+		{"center and right", 0x03, 0x05 | 0x00<<3, []string{"Mylla & Youlla", "Farlla"}},
+		// with Farlla and Golla taken, left index 2 counts Estlla, Pilla, Leilla
+		{"all three", 0x07, 0x00 | 0x02<<3 | 0x02<<6, []string{"Farlla", "Golla", "Leilla"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blasts := decodePhotonBlasts(tt.has, tt.pb)
+			if len(blasts) != len(tt.blasts) {
+				t.Fatalf("decodePhotonBlasts() = %v, want %v", blasts, tt.blasts)
+			}
+			for i := range blasts {
+				if blasts[i] != tt.blasts[i] {
+					t.Errorf("decodePhotonBlasts() = %v, want %v", blasts, tt.blasts)
+				}
+			}
+		})
+	}
+	if blast := (Mag{PhotonBlasts: []string{"Farlla", "Golla", "Pilla"}}).PhotonBlast(); blast != "" {
+		t.Errorf("Expected no blast named for a mag with several but got '%v'", blast)
+	}
+}
@@ -0,0 +1,147 @@
+package pso
+
+import (
+	"errors"
+	"log"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+const (
+	// Every field we need from a monster is between these offsets, zu/pazuzu's height offset is the last
+	monsterStructBase = uintptr(0x1C)
+	monsterStructMax  = uintptr(0x41C)
+	// De Rol Le and Barba Ray keep their hp further into a much bigger struct
+	bossStructBase = monsterDeRolLeShellHP
+	bossStructMax  = monsterBarbaRayShellHP + 2
+
+	ephineaMonsterSize = 32
+)
+
+// GetMonsterList reads every monster in the npc array. Each monster struct is read in one block and decoded
+// by ParseMonsterMemory, bosses need one more read and ephinea's hp table is read once for all monsters.
+func (pso *PSO) GetMonsterList() ([]Monster, error) {
+	npcArrayAddr := uintptr(numbers.ReadU32Unchecked(pso.process, pso.offsets.Addresses.NpcArrayPointer))
+	npcCount := int(numbers.ReadU32Unchecked(pso.process, pso.offsets.Addresses.NpcCount))
+	playerCount := int(pso.getPlayerCount())
+	ephineaMonsters := uintptr(0)
+	if pso.offsets.Addresses.EphineaMonsters != 0 {
+		ephineaMonsters = uintptr(numbers.ReadU32Unchecked(pso.process, pso.offsets.Addresses.EphineaMonsters))
+	}
+
+	buf, ok := pso.process.ReadMemory(npcArrayAddr, uintptr(4*(playerCount+npcCount+1)))
+	if !ok {
+		return nil, errors.New("unable to GetMonsterList")
+	}
+	parsed := make([]Monster, 0, npcCount)
+	bosses := make(map[int]bool)
+	maxMonsterId := -1
+	for i := playerCount; i < (playerCount + npcCount); i++ {
+		monsterAddr := uintptr(numbers.Uint32FromU16(buf[2*i], buf[(2*i)+1]))
+		if monsterAddr == 0 {
+			continue
+		}
+		monsterBuf, ok := pso.process.ReadMemory(monsterAddr+monsterStructBase, monsterStructMax-monsterStructBase)
+		if !ok {
+			return nil, errors.New("unable to read monster")
+		}
+		monster := ParseMonsterMemory(monsterBuf, monsterStructBase)
+		monster.Index = i
+		if monster.UnitxtId == 45 || monster.UnitxtId == 73 {
+			bossBuf, ok := pso.process.ReadMemory(monsterAddr+bossStructBase, bossStructMax-bossStructBase)
+			if !ok {
+				return nil, errors.New("unable to read boss monster")
+			}
+			monster.hp = parseBossHp(bossBuf, bossStructBase, monster)
+			bosses[len(parsed)] = true
+		}
+		if int(monster.Id) > maxMonsterId {
+			maxMonsterId = int(monster.Id)
+		}
+		parsed = append(parsed, monster)
+	}
+
+	if ephineaMonsters != 0 && maxMonsterId >= 0 {
+		ephineaBuf, ok := pso.process.ReadMemory(ephineaMonsters, uintptr(ephineaMonsterSize*(maxMonsterId+1)))
+		if !ok {
+			return nil, errors.New("unable to read ephinea monster hp")
+		}
+		for i := range parsed {
+			if !bosses[i] {
+				parsed[i].hp = ephineaBuf[(0x04+(uintptr(parsed[i].Id)*ephineaMonsterSize))/2]
+			}
+		}
+	}
+
+	monsterCount := 0
+	monsters := make([]Monster, 0, len(parsed))
+	for _, monster := range parsed {
+		// underflow seems to be possible
+		if monster.hp > 0x8000 {
+			monster.overkill = uint16(0x10000 - int(monster.hp))
+			monster.hp = 0
+		}
+		monster.Location.HP = monster.hp
+		if monster.UnitxtId == 0 {
+			continue
+		}
+		monsterName, err := pso.getMonsterName(monster.UnitxtId)
+		if err != nil {
+			log.Printf("cannot read monster name for id %v %v", monster.UnitxtId, err)
+			continue
+		}
+		monster.Name = monsterName
+		monsters = append(monsters, monster)
+		if monster.hp > 0 {
+			monsterCount++
+		}
+	}
+	pso.GameState.MonsterCount = monsterCount
+	return monsters, nil
+}
+
+// ParseMonsterMemory decodes a monster struct read from base, leaving Name, Index and boss hp to the caller
+func ParseMonsterMemory(buf []uint16, base uintptr) Monster {
+	unitxtId := numbers.Uint32FromU16(buf[(0x378-base)/2], buf[(0x37A-base)/2])
+	monsterStatus := buf[(0x268-base)/2]
+	hp := buf[(0x334-base)/2]
+	monsterY := numbers.Float32FromU16(buf[(0x3C-base)/2], buf[(0x3E-base)/2])
+	if unitxtId == 94 || unitxtId == 95 {
+		// zu and pazuzu have weird height logic
+		monsterY += numbers.Float32FromU16(buf[(0x418-base)/2], buf[(0x41A-base)/2])
+	}
+	return Monster{
+		hp:              hp,
+		Id:              buf[(0x1C-base)/2],
+		UnitxtId:        unitxtId,
+		LastAttackerIdx: buf[(0x2D8-base)/2],
+		Location: model.MonsterLocation{
+			HP:        hp,
+			Facing:    buf[(0x60-base)/2],
+			Frozen:    monsterStatus == 0x02,
+			Paralyzed: buf[(0x25C-base)/2] == 0x10,
+			Confused:  monsterStatus == 0x12,
+			X:         numbers.Float32FromU16(buf[(0x38-base)/2], buf[(0x3A-base)/2]),
+			Y:         monsterY,
+			Z:         numbers.Float32FromU16(buf[(0x40-base)/2], buf[(0x42-base)/2]),
+		},
+	}
+}
+
+func parseBossHp(buf []uint16, base uintptr, monster Monster) uint16 {
+	if monster.UnitxtId == 45 {
+		// DRL
+		if monster.Index == 0 {
+			// todo Missing skull hp atm
+			return buf[(monsterDeRolLeHP-base)/2]
+		}
+		return buf[(monsterDeRolLeShellHP-base)/2]
+	}
+	// Barba Ray
+	if monster.Index == 0 {
+		// todo Missing skull hp atm
+		return buf[(monsterBarbaRayHP-base)/2]
+	}
+	return buf[(monsterBarbaRayShellHP-base)/2]
+}
@@ -0,0 +1,144 @@
+package pso
+
+import (
+	"testing"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+)
+
+const (
+	testMonsterNames   = uintptr(0x01310000)
+	testMonsters       = uintptr(0x01400000)
+	testMonsterSize    = uintptr(0x800)
+	testEphineaMonster = uintptr(0x01800000)
+	testRoomSize       = 120
+)
+
+type countingProcess struct {
+	Process
+	reads int
+}
+
+func (p *countingProcess) ReadMemory(address uintptr, size uintptr) ([]uint16, bool) {
+	p.reads++
+	return p.Process.ReadMemory(address, size)
+}
+
+// addMonsters fills the npc array after our one player with count monsters, all booma with 100 hp
+func addMonsters(memory *numbers.FakeMemory, count int) {
+	memory.WriteU32(0x00a9cd50, uint32(testUnitxt))
+	memory.Zero(testUnitxt, 32)
+	memory.WriteU32(testUnitxt+16, uint32(testMonsterNames))
+	memory.Zero(testMonsterNames, 4*128)
+	memory.WriteU32(testMonsterNames+4*9, uint32(testMonsterNames+0x400))
+	memory.WriteString(testMonsterNames+0x400, "Booma")
+
+	memory.WriteU32(0x00AAE164, uint32(count))
+	for i := 0; i < count; i++ {
+		monsterAddr := testMonsters + uintptr(i)*testMonsterSize
+		memory.Zero(monsterAddr, int(testMonsterSize))
+		memory.WriteU16(monsterAddr+0x1C, uint16(i))
+		memory.WriteU32(monsterAddr+0x378, 9)
+		memory.WriteU16(monsterAddr+0x334, 100)
+		memory.WriteU16(monsterAddr+0x2D8, 0)
+		memory.WriteF32(monsterAddr+0x38, float32(i))
+		memory.WriteU32(testNpcArray+uintptr(4*(i+1)), uint32(monsterAddr))
+	}
+}
+
+func TestGetMonsterList_DecodesMonsters(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	addMonsters(memory, 3)
+	memory.WriteU16(testMonsters+testMonsterSize+0x268, 0x02)
+	memory.WriteU16(testMonsters+2*testMonsterSize+0x334, 0)
+
+	monsters, err := pso.GetMonsterList()
+	if err != nil {
+		t.Fatalf("GetMonsterList: %v", err)
+	}
+	if len(monsters) != 3 {
+		t.Fatalf("Expected 3 monsters but got %v", len(monsters))
+	}
+	if monsters[1].Name != "Booma" || monsters[1].Id != 1 || monsters[1].Index != 2 {
+		t.Errorf("Unexpected monster %v(%v) at index %v", monsters[1].Name, monsters[1].Id, monsters[1].Index)
+	}
+	if !monsters[1].Location.Frozen || monsters[1].Location.X != 1 {
+		t.Errorf("Expected a frozen monster at x=1 but got %+v", monsters[1].Location)
+	}
+	if pso.GameState.MonsterCount != 2 {
+		t.Errorf("Expected 2 living monsters but got %v", pso.GameState.MonsterCount)
+	}
+}
+
+func TestGetMonsterList_EphineaHp(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	addMonsters(memory, 2)
+	memory.WriteU32(0x00B5F800, uint32(testEphineaMonster))
+	memory.Zero(testEphineaMonster, 2*ephineaMonsterSize)
+	memory.WriteU16(testEphineaMonster+ephineaMonsterSize+0x04, 4000)
+
+	monsters, err := pso.GetMonsterList()
+	if err != nil {
+		t.Fatalf("GetMonsterList: %v", err)
+	}
+	if monsters[0].hp != 0 || monsters[1].hp != 4000 || monsters[1].Location.HP != 4000 {
+		t.Errorf("Expected hp from ephinea's table [0 4000] but got [%v %v]", monsters[0].hp, monsters[1].hp)
+	}
+}
+
+func TestGetMonsterList_OneReadPerMonster(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	addMonsters(memory, testRoomSize)
+	process := &countingProcess{Process: pso.process}
+	pso.process = process
+
+	if _, err := pso.GetMonsterList(); err != nil {
+		t.Fatalf("GetMonsterList: %v", err)
+	}
+	process.reads = 0
+	if _, err := pso.GetMonsterList(); err != nil {
+		t.Fatalf("GetMonsterList: %v", err)
+	}
+	// npc array pointer, npc count, player count, ephinea table pointer, npc array
+	if expected := 5 + testRoomSize; process.reads != expected {
+		t.Errorf("Expected %v reads but got %v", expected, process.reads)
+	}
+}
+
+func BenchmarkGetMonsterList(b *testing.B) {
+	pso, memory := newSyntheticPso()
+	addMonsters(memory, testRoomSize)
+	process := &countingProcess{Process: pso.process}
+	pso.process = process
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pso.GetMonsterList(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(process.reads)/float64(b.N), "reads/op")
+}
+
+// BenchmarkMonsterFieldReads reads the same room a field at a time, the way GetMonsterList used to
+func BenchmarkMonsterFieldReads(b *testing.B) {
+	pso, memory := newSyntheticPso()
+	addMonsters(memory, testRoomSize)
+	process := &countingProcess{Process: pso.process}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for m := 0; m < testRoomSize; m++ {
+			monsterAddr := testMonsters + uintptr(m)*testMonsterSize
+			numbers.ReadU16(process, monsterAddr+0x1c)
+			numbers.ReadU32(process, monsterAddr+0x378)
+			numbers.ReadU16(process, monsterAddr+0x60)
+			numbers.ReadU16(process, monsterAddr+0x2D8)
+			numbers.ReadU16(process, monsterAddr+0x334)
+			numbers.ReadU16(process, monsterAddr+0x268)
+			numbers.ReadU16(process, monsterAddr+0x25C)
+			numbers.ReadF32(process, monsterAddr+0x3C)
+			numbers.ReadF32(process, monsterAddr+0x38)
+			numbers.ReadF32(process, monsterAddr+0x40)
+		}
+	}
+	b.ReportMetric(float64(process.reads)/float64(b.N), "reads/op")
+}
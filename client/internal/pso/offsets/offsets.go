@@ -0,0 +1,157 @@
+// Memory offset profiles, so a game patch only needs a new profile instead of a new client
+package offsets
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+const SupportedVersion = 1
+
+//go:embed profiles.yaml
+var embeddedProfiles []byte
+
+type Profiles struct {
+	Version  int       `yaml:"version"`
+	Profiles []Profile `yaml:"profiles"`
+}
+
+type Profile struct {
+	Server    string    `yaml:"server"`
+	Build     string    `yaml:"build"`
+	Addresses Addresses `yaml:"addresses"`
+	Quest     Quest     `yaml:"quest"`
+	Item      Item      `yaml:"item"`
+}
+
+// Addresses are absolute locations in the pso process
+type Addresses struct {
+	BasePlayerArray  uintptr `yaml:"basePlayerArray"`
+	MyPlayerIndex    uintptr `yaml:"myPlayerIndex"`
+	Episode          uintptr `yaml:"episode"`
+	Difficulty       uintptr `yaml:"difficulty"`
+	Map              uintptr `yaml:"map"`
+	MapVariation     uintptr `yaml:"mapVariation"`
+	Floor            uintptr `yaml:"floor"`
+	FloorSwitches    uintptr `yaml:"floorSwitches"`
+	RngSeed          uintptr `yaml:"rngSeed"`
+	PlayerCount      uintptr `yaml:"playerCount"`
+	NpcCount         uintptr `yaml:"npcCount"`
+	NpcArrayPointer  uintptr `yaml:"npcArrayPointer"`
+	EphineaMonsters  uintptr `yaml:"ephineaMonsters"`
+	EphineaSlowBurst uintptr `yaml:"ephineaSlowBurst"`
+	UnitxtPointer    uintptr `yaml:"unitxtPointer"`
+	PmtPointer       uintptr `yaml:"pmtPointer"`
+	QuestPointer     uintptr `yaml:"questPointer"`
+	ItemArray        uintptr `yaml:"itemArray"`
+	ItemArrayCount   uintptr `yaml:"itemArrayCount"`
+}
+
+// Quest offsets are relative to the quest struct, or to the quest data struct for Number and Name
+type Quest struct {
+	DataPointer     uintptr `yaml:"dataPointer"`
+	RegisterPointer uintptr `yaml:"registerPointer"`
+	Number          uintptr `yaml:"number"`
+	Name            uintptr `yaml:"name"`
+}
+
+// Item offsets are relative to an entry in the item array
+type Item struct {
+	Id           uintptr `yaml:"id"`
+	Owner        uintptr `yaml:"owner"`
+	Kills        uintptr `yaml:"kills"`
+	Type         uintptr `yaml:"type"`
+	Group        uintptr `yaml:"group"`
+	Index        uintptr `yaml:"index"`
+	Equipped     uintptr `yaml:"equipped"`
+	WepGrind     uintptr `yaml:"wepGrind"`
+	WepSpecial   uintptr `yaml:"wepSpecial"`
+	WepStats     uintptr `yaml:"wepStats"`
+	ArmSlots     uintptr `yaml:"armSlots"`
+	FrameDfp     uintptr `yaml:"frameDfp"`
+	FrameEvp     uintptr `yaml:"frameEvp"`
+	BarrierDfp   uintptr `yaml:"barrierDfp"`
+	BarrierEvp   uintptr `yaml:"barrierEvp"`
+	UnitMod      uintptr `yaml:"unitMod"`
+	MagStats     uintptr `yaml:"magStats"`
+	MagPBHas     uintptr `yaml:"magPBHas"`
+	MagPB        uintptr `yaml:"magPB"`
+	MagColor     uintptr `yaml:"magColor"`
+	MagSync      uintptr `yaml:"magSync"`
+	MagIQ        uintptr `yaml:"magIQ"`
+	MagTimer     uintptr `yaml:"magTimer"`
+	ToolCount    uintptr `yaml:"toolCount"`
+	TechType     uintptr `yaml:"techType"`
+	MesetaAmount uintptr `yaml:"mesetaAmount"`
+}
+
+// Default returns the profiles embedded in the client
+func Default() Profiles {
+	profiles, err := parse(embeddedProfiles)
+	if err != nil {
+		panic(fmt.Sprintf("embedded offset profiles are invalid: %v", err))
+	}
+	return profiles
+}
+
+// Load returns the embedded profiles, with any profiles in overridePath taking precedence. A missing override is not an error.
+func Load(overridePath string) (Profiles, error) {
+	profiles := Default()
+	data, err := os.ReadFile(overridePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	} else if err != nil {
+		return profiles, err
+	}
+	overrides, err := parse(data)
+	if err != nil {
+		return profiles, fmt.Errorf("%v: %w", overridePath, err)
+	}
+	overrides.Profiles = append(overrides.Profiles, profiles.Profiles...)
+	return overrides, nil
+}
+
+func parse(data []byte) (Profiles, error) {
+	profiles := Profiles{}
+	if err := yaml.UnmarshalStrict(data, &profiles); err != nil {
+		return profiles, err
+	}
+	if profiles.Version != SupportedVersion {
+		return profiles, fmt.Errorf("unsupported offset profile version %v, expected %v", profiles.Version, SupportedVersion)
+	}
+	for _, profile := range profiles.Profiles {
+		if err := profile.validate(); err != nil {
+			return profiles, fmt.Errorf("profile server:'%v' build:'%v' %w", profile.Server, profile.Build, err)
+		}
+	}
+	return profiles, nil
+}
+
+func (p Profile) validate() error {
+	a := p.Addresses
+	if a.BasePlayerArray == 0 || a.MyPlayerIndex == 0 || a.Episode == 0 || a.QuestPointer == 0 || a.ItemArray == 0 {
+		return errors.New("is missing required addresses")
+	}
+	if a.Difficulty < a.Episode {
+		return errors.New("difficulty must come after episode, they're read as one block")
+	}
+	return nil
+}
+
+// Select picks the profile for a server and client build, falling back to the server's
+// build-less profile and then to the generic profile. Earlier profiles win ties.
+func (p Profiles) Select(server string, build string) (Profile, bool) {
+	candidates := [][2]string{{server, build}, {server, ""}, {"", ""}}
+	for _, candidate := range candidates {
+		for _, profile := range p.Profiles {
+			if profile.Server == candidate[0] && profile.Build == candidate[1] {
+				return profile, true
+			}
+		}
+	}
+	return Profile{}, false
+}
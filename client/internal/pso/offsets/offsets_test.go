@@ -0,0 +1,90 @@
+package offsets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
+)
+
+const overrideProfiles = `
+version: 1
+profiles:
+  - server: Ephinea
+    build: "1.25.13"
+    addresses:
+      basePlayerArray: 0x00B00000
+      myPlayerIndex: 0x00A9C4F4
+      episode: 0x00A9B1C8
+      difficulty: 0x00A9CD68
+      questPointer: 0x00A95AA8
+      itemArray: 0x00A8D81C
+`
+
+func TestDefault_SelectsGenericProfileForAnyServer(t *testing.T) {
+	profile, found := offsets.Default().Select("Ephinea", "1.25.13")
+	if !found {
+		t.Fatal("Expected the generic profile to be selected")
+	}
+	if profile.Addresses.BasePlayerArray != 0x00A94254 {
+		t.Errorf("Expected basePlayerArray 0x00A94254 but got 0x%08x", profile.Addresses.BasePlayerArray)
+	}
+	if profile.Quest.DataPointer != 0x19C {
+		t.Errorf("Expected quest dataPointer 0x19C but got 0x%x", profile.Quest.DataPointer)
+	}
+}
+
+func TestLoad_MissingOverrideUsesDefaults(t *testing.T) {
+	profiles, err := offsets.Load(filepath.Join(t.TempDir(), "offsets.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(profiles.Profiles) != len(offsets.Default().Profiles) {
+		t.Errorf("Expected only the embedded profiles but got %v", len(profiles.Profiles))
+	}
+}
+
+func TestLoad_OverrideTakesPrecedence(t *testing.T) {
+	profiles, err := offsets.Load(writeOverride(overrideProfiles, t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	profile, _ := profiles.Select("Ephinea", "1.25.13")
+	if profile.Addresses.BasePlayerArray != 0x00B00000 {
+		t.Errorf("Expected the override basePlayerArray but got 0x%08x", profile.Addresses.BasePlayerArray)
+	}
+	profile, _ = profiles.Select("Ephinea", "1.25.14")
+	if profile.Addresses.BasePlayerArray != 0x00A94254 {
+		t.Errorf("Expected other builds to fall back to the generic profile but got 0x%08x", profile.Addresses.BasePlayerArray)
+	}
+}
+
+func TestLoad_RejectsUnsupportedVersion(t *testing.T) {
+	_, err := offsets.Load(writeOverride("version: 2\nprofiles: []\n", t))
+	if err == nil {
+		t.Error("Expected an error for version 2")
+	}
+}
+
+func TestLoad_RejectsMissingAddresses(t *testing.T) {
+	_, err := offsets.Load(writeOverride("version: 1\nprofiles:\n  - server: Ephinea\n", t))
+	if err == nil {
+		t.Error("Expected an error for a profile without addresses")
+	}
+}
+
+func TestLoad_RejectsUnknownFields(t *testing.T) {
+	_, err := offsets.Load(writeOverride("version: 1\nprofile: []\n", t))
+	if err == nil {
+		t.Error("Expected an error for a misspelled field")
+	}
+}
+
+func writeOverride(contents string, t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "offsets.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 	"unicode/utf16"
 
-	"github.com/TheTitanrain/w32"
 	"github.com/phelix-/psostats/v2/client/internal/numbers"
 
 	constants "github.com/phelix-/psostats/v2/client/internal/pso/constants"
@@ -20,6 +19,7 @@ const (
 )
 
 type BasePlayerInfo struct {
+	Slot                uint8 // party slot, monsters record their last attacker by it
 	Name                string
 	GuildCard           string
 	SectionId           uint8
@@ -44,6 +44,7 @@ type BasePlayerInfo struct {
 	AccountMode         constants.EphineaAccountMode
 	ActionState         uint16
 	currentTech         uint16
+	currentTechLevel    uint16
 	Location            model.Location
 }
 
@@ -92,6 +93,11 @@ func (p BasePlayerInfo) GetCurrentTech() string {
 	}
 }
 
+// GetCurrentTechLevel is the level of the tech being cast, pso keeps it zero based like the character level
+func (p BasePlayerInfo) GetCurrentTechLevel() uint16 {
+	return p.currentTechLevel + 1
+}
+
 func ParsePlayerMemory(buf []uint16, base uintptr) BasePlayerInfo {
 	shiftaMultiplier := numbers.Float32FromU16(buf[(0x278-base)/2], buf[(0x27A-base)/2])
 	debandMultiplier := numbers.Float32FromU16(buf[(0x278+12-base)/2], buf[(0x278+14-base)/2])
@@ -125,6 +131,7 @@ func ParsePlayerMemory(buf []uint16, base uintptr) BasePlayerInfo {
 		Meseta:              numbers.Uint32FromU16(buf[(0xE4C-base)/2], buf[(0xE4E-base)/2]),
 		ActionState:         buf[(0x348-base)/2],
 		currentTech:         buf[(0x464-base)/2],
+		currentTechLevel:    buf[(0x466-base)/2],
 		Location: model.Location{
 			Floor:   floor,
 			Room:    room,
@@ -137,21 +144,21 @@ func ParsePlayerMemory(buf []uint16, base uintptr) BasePlayerInfo {
 	}
 }
 
-func GetPlayerData(handle w32.HANDLE, playerAddress uintptr, server string) (BasePlayerInfo, error) {
+func GetPlayerData(memory numbers.MemoryReader, playerAddress uintptr, server string) (BasePlayerInfo, error) {
 	base := uintptr(0x028)
 	max := uintptr(0xE4E)
-	buf, _, ok := w32.ReadProcessMemory(handle, playerAddress+base, (max-base)+4)
+	buf, ok := memory.ReadMemory(playerAddress+base, (max-base)+4)
 	if !ok {
 		return BasePlayerInfo{}, errors.New("unable to getPlayerData")
 	}
 	basePlayerInfo := ParsePlayerMemory(buf, base)
-	name, err := getCharacterName(handle, playerAddress)
+	name, err := getCharacterName(memory, playerAddress)
 	if err != nil {
 		return BasePlayerInfo{}, err
 	}
 	basePlayerInfo.Name = name
 
-	guildcard, err := getGuildCard(handle, playerAddress, server)
+	guildcard, err := getGuildCard(memory, playerAddress, server)
 	if err != nil {
 		return BasePlayerInfo{}, err
 	}
@@ -159,7 +166,7 @@ func GetPlayerData(handle w32.HANDLE, playerAddress uintptr, server string) (Bas
 
 	basePlayerInfo.AccountMode = constants.Normal
 	if server == constants.EphineaServerName {
-		mode, err := getEphineaAccountMode(handle, playerAddress)
+		mode, err := getEphineaAccountMode(memory, playerAddress)
 		if err != nil {
 			return BasePlayerInfo{}, err
 		}
@@ -171,8 +178,8 @@ func GetPlayerData(handle w32.HANDLE, playerAddress uintptr, server string) (Bas
 	return basePlayerInfo, nil
 }
 
-func getCharacterName(handle w32.HANDLE, playerAddress uintptr) (string, error) {
-	buf, _, ok := w32.ReadProcessMemory(handle, playerAddress+0x428, 24)
+func getCharacterName(memory numbers.MemoryReader, playerAddress uintptr) (string, error) {
+	buf, ok := memory.ReadMemory(playerAddress+0x428, 24)
 	if !ok {
 		return "", errors.New("unable to getCharacterName")
 	}
@@ -190,7 +197,7 @@ func getCharacterName(handle w32.HANDLE, playerAddress uintptr) (string, error)
 	return name, nil
 }
 
-func getGuildCard(handle w32.HANDLE, playerAddress uintptr, server string) (string, error) {
+func getGuildCard(memory numbers.MemoryReader, playerAddress uintptr, server string) (string, error) {
 	offset := 0
 	size := 7
 	if server == constants.EphineaServerName {
@@ -198,7 +205,7 @@ func getGuildCard(handle w32.HANDLE, playerAddress uintptr, server string) (stri
 		size = ephineaGuildCardSize
 	}
 
-	guildCard, err := numbers.ReadString(handle, playerAddress+0x92c+uintptr(offset), size)
+	guildCard, err := numbers.ReadString(memory, playerAddress+0x92c+uintptr(offset), size)
 	if err != nil {
 		return "", err
 	}
@@ -211,8 +218,8 @@ func getGuildCard(handle w32.HANDLE, playerAddress uintptr, server string) (stri
 	return guildCard, nil
 }
 
-func getEphineaAccountMode(handle w32.HANDLE, playerAddress uintptr) (constants.EphineaAccountMode, error) {
-	buf, _, ok := w32.ReadProcessMemory(handle, playerAddress+0x948, 3)
+func getEphineaAccountMode(memory numbers.MemoryReader, playerAddress uintptr) (constants.EphineaAccountMode, error) {
+	buf, ok := memory.ReadMemory(playerAddress+0x948, 3)
 	if !ok {
 		return constants.Normal, errors.New("unable to getEphineaAccountMode")
 	}
@@ -0,0 +1,86 @@
+package pso
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+
+	constants "github.com/phelix-/psostats/v2/client/internal/pso/constants"
+)
+
+// Under wine the exe shows up in the cmdline as a windows path, e.g. C:\Games\Ephinea\PsoBB.exe
+const psoExecutable = "psobb.exe"
+
+type linuxProcess struct {
+	*numbers.ProcMemory
+}
+
+func (p linuxProcess) Running() bool {
+	return syscall.Kill(p.Pid, 0) == nil
+}
+
+func (p linuxProcess) Close() {
+	p.ProcMemory.Close()
+}
+
+func (pso *PSO) Connect() (bool, string, error) {
+	pid, server, found := findPsoProcess("/proc")
+	if !found {
+		return false, "Process not found", nil
+	}
+
+	memory, err := numbers.OpenProcMemory(pid)
+	if err != nil {
+		return false, "Could not open process", fmt.Errorf("Connect: could not open process with pid %v: %w", pid, err)
+	}
+
+	process := linuxProcess{memory}
+	if err := pso.attach(process, server); err != nil {
+		process.Close()
+		return false, "No memory offsets for server", err
+	}
+
+	return true, fmt.Sprintf("Connected to pid %v", pid), nil
+}
+
+func findPsoProcess(procDir string) (int, string, bool) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return 0, "", false
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(procDir, entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		if server, isPso := matchPsoCmdline(cmdline); isPso {
+			return pid, server, true
+		}
+	}
+	return 0, "", false
+}
+
+// matchPsoCmdline checks whether a nul separated /proc/<pid>/cmdline was started as the pso exe. Only the first
+// argument counts, wine's start.exe and launchers pass the exe along as a later one. There's no window title to
+// tell the servers apart, so we go by the install path, which Ephinea's installer names after itself.
+func matchPsoCmdline(cmdline []byte) (string, bool) {
+	path := strings.ToLower(string(bytes.SplitN(cmdline, []byte{0}, 2)[0]))
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	if name != psoExecutable {
+		return "", false
+	}
+	if strings.Contains(path, constants.EphineaServerName) {
+		return constants.EphineaServerName, true
+	}
+	return constants.UnseenServerName, true
+}
@@ -0,0 +1,52 @@
+package pso
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso/constants"
+)
+
+func TestMatchPsoCmdline(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		server  string
+		isPso   bool
+	}{
+		{`C:\Games\EphineaPSO\PsoBB.exe`, constants.EphineaServerName, true},
+		{`Z:\home\phelix\.wine\drive_c\PSOBB\psobb.exe` + "\x00-unused", constants.UnseenServerName, true},
+		{"/home/phelix/Ephinea/psobb.exe", constants.EphineaServerName, true},
+		{`C:\windows\system32\start.exe` + "\x00/unix\x00/home/phelix/Ephinea/psobb.exe", "", false},
+		{`C:\Games\EphineaPSO\online.exe`, "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		server, isPso := matchPsoCmdline([]byte(test.cmdline))
+		if server != test.server || isPso != test.isPso {
+			t.Errorf("%q: expected %v %v but got %v %v", test.cmdline, test.server, test.isPso, server, isPso)
+		}
+	}
+}
+
+func TestFindPsoProcess(t *testing.T) {
+	procDir := t.TempDir()
+	processes := map[string]string{
+		"1":    "/sbin/init",
+		"self": `C:\Games\EphineaPSO\PsoBB.exe`,
+		"4242": `C:\Games\EphineaPSO\PsoBB.exe`,
+	}
+	for pid, cmdline := range processes {
+		if err := os.MkdirAll(filepath.Join(procDir, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(procDir, pid, "cmdline"), []byte(cmdline+"\x00"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pid, server, found := findPsoProcess(procDir)
+	if !found || pid != 4242 || server != constants.EphineaServerName {
+		t.Errorf("Expected pid 4242 on ephinea but got %v %v %v", pid, server, found)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows && !linux
+
+package pso
+
+func (pso *PSO) Connect() (bool, string, error) {
+	return false, "Unsupported platform", nil
+}
@@ -0,0 +1,57 @@
+package pso
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/TheTitanrain/w32"
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+
+	constants "github.com/phelix-/psostats/v2/client/internal/pso/constants"
+)
+
+const (
+	unseenWindowName       = "PHANTASY STAR ONLINE Blue Burst"
+	ephineaWindowName      = "Ephinea: Phantasy Star Online Blue Burst"
+	windowsCodeStillActive = 259
+)
+
+type windowsProcess struct {
+	numbers.W32Memory
+}
+
+func (p windowsProcess) Running() bool {
+	code, err := w32.GetExitCodeProcess(p.Handle)
+	return err == nil && code == windowsCodeStillActive
+}
+
+func (p windowsProcess) Close() {
+	w32.CloseHandle(p.Handle)
+}
+
+func (pso *PSO) Connect() (bool, string, error) {
+	server := constants.UnseenServerName
+	hwnd := w32.FindWindowW(nil, syscall.StringToUTF16Ptr(unseenWindowName))
+	if hwnd == 0 {
+		server = constants.EphineaServerName
+		// unseen not found
+		hwnd = w32.FindWindowW(nil, syscall.StringToUTF16Ptr(ephineaWindowName))
+		if hwnd == 0 {
+			return false, "Window not found", nil
+		}
+	}
+
+	_, pid := w32.GetWindowThreadProcessId(hwnd)
+	handle, err := w32.OpenProcess(w32.PROCESS_ALL_ACCESS, false, uintptr(pid))
+	if err != nil {
+		return false, "Could not open process", fmt.Errorf("Connect: could not open process with pid %v: %w", pid, err)
+	}
+
+	process := windowsProcess{numbers.W32Memory{Handle: handle}}
+	if err := pso.attach(process, server); err != nil {
+		process.Close()
+		return false, "No memory offsets for server", err
+	}
+
+	return true, fmt.Sprintf("Connected to pid %v", pid), nil
+}
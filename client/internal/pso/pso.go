@@ -4,23 +4,20 @@ import (
 	"fmt"
 	"github.com/phelix-/psostats/v2/pkg/model"
 	"log"
-	"syscall"
+	"path/filepath"
 	"time"
 
 	"github.com/phelix-/psostats/v2/client/internal/pso/inventory"
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
 	"github.com/phelix-/psostats/v2/client/internal/pso/quest"
 
-	"github.com/TheTitanrain/w32"
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
 	"github.com/phelix-/psostats/v2/client/internal/pso/player"
-
-	constants "github.com/phelix-/psostats/v2/client/internal/pso/constants"
+	"github.com/phelix-/psostats/v2/client/internal/trace"
 )
 
 const (
-	unseenWindowName             = "PHANTASY STAR ONLINE Blue Burst"
-	ephineaWindowName            = "Ephinea: Phantasy Star Online Blue Burst"
 	persistentConnectionTickRate = time.Second / 30
-	windowsCodeStillActive       = 259
 )
 
 type PSO struct {
@@ -30,15 +27,31 @@ type PSO struct {
 	connected          bool
 	connectedStatus    string
 	server             string
-	handle             w32.HANDLE
+	process            Process
+	offsetProfiles     offsets.Profiles
+	offsetBuild        string
+	offsets            offsets.Profile
 	CurrentPlayerData  player.BasePlayerInfo
 	CurrentPlayerIndex uint8
 	Inventory          inventory.Inventory
+	CurrentMonsters    []Monster // Every monster read on the last tick, dead ones included
 	GameState          GameState
 	CurrentQuest       QuestRun
 	errors             chan error
 	done               chan struct{}
 	MonsterNames       map[uint32]string
+	tickTime           time.Time
+	traceDir           string
+	recorder           *trace.Recorder
+	eventListeners     []QuestEventListener
+	dataFrameRate      int
+}
+
+// Process is an open pso client, provided by the platform specific backend that found it
+type Process interface {
+	numbers.MemoryReader
+	Running() bool
+	Close()
 }
 
 type GameState struct {
@@ -62,6 +75,7 @@ type GameState struct {
 	questPointer         uintptr
 	questRegisterPointer uintptr
 	CurrentSplit         model.QuestRunSplit
+	waveStart            time.Time // Monsters spawned from here on are the current split's wave
 }
 
 func (state *GameState) ClearQuest() {
@@ -76,6 +90,7 @@ func (state *GameState) ClearQuest() {
 	state.questRegisterPointer = 0
 	state.questPointer = 0
 	state.CurrentSplit = model.QuestRunSplit{}
+	state.waveStart = time.Time{}
 }
 
 func (state *GameState) Clear() {
@@ -102,12 +117,46 @@ type PlayerData struct {
 }
 
 func New(startedGameChannel chan QuestRun, completeGameChannel chan QuestRun) *PSO {
+	profiles := offsets.Default()
+	profile, _ := profiles.Select("", "")
 	return &PSO{
-		startedGame:  startedGameChannel,
-		completeGame: completeGameChannel,
-		questTypes:   quest.NewQuests(),
-		MonsterNames: make(map[uint32]string),
+		startedGame:    startedGameChannel,
+		completeGame:   completeGameChannel,
+		questTypes:     quest.NewQuests(),
+		MonsterNames:   make(map[uint32]string),
+		offsetProfiles: profiles,
+		offsets:        profile,
+	}
+}
+
+// SetOffsetProfiles replaces the memory offset profiles, build picks a client build specific profile when one exists
+func (pso *PSO) SetOffsetProfiles(profiles offsets.Profiles, build string) {
+	pso.offsetProfiles = profiles
+	pso.offsetBuild = build
+}
+
+// SetDataFrameRate samples delta encoded data frames framesPerSecond times a second, up to the tick rate.
+// Zero keeps one full frame a second.
+func (pso *PSO) SetDataFrameRate(framesPerSecond int) {
+	pso.dataFrameRate = framesPerSecond
+}
+
+// LoadQuestDefinitions merges quests from definitionFiles with the built in quests, returning any problems found
+func (pso *PSO) LoadQuestDefinitions(definitionFiles ...string) []string {
+	pso.questTypes = quest.NewQuests(definitionFiles...)
+	return pso.questTypes.Warnings()
+}
+
+// attach starts reading from process, using the offset profile for the server it's connected to
+func (pso *PSO) attach(process Process, server string) error {
+	profile, found := pso.offsetProfiles.Select(server, pso.offsetBuild)
+	if !found {
+		return fmt.Errorf("attach: no memory offset profile for server '%v' build '%v'", server, pso.offsetBuild)
 	}
+	pso.process = process
+	pso.server = server
+	pso.offsets = profile
+	return nil
 }
 
 func (pso *PSO) StartPersistentConnection(errors chan error) {
@@ -130,10 +179,22 @@ func (pso *PSO) StartPersistentConnection(errors chan error) {
 					if !pso.connected {
 						continue
 					}
+					if len(pso.traceDir) > 0 {
+						pso.startRecording()
+					}
 				}
 				pso.connected = pso.checkConnection()
-				if pso.connected {
-					err := pso.RefreshData()
+				if !pso.connected {
+					pso.Close()
+				} else {
+					tickTime := time.Now()
+					if pso.recorder != nil {
+						if err := pso.recorder.StartTick(tickTime); err != nil {
+							log.Printf("Unable to record trace, stopping recording: %v", err)
+							pso.recorder = nil
+						}
+					}
+					err := pso.refreshDataAt(tickTime)
 					if err != nil {
 						log.Fatal(err)
 						errors <- fmt.Errorf("StartPersistentConnection: could not refresh data: %w", err)
@@ -155,32 +216,50 @@ func (pso *PSO) StopPersistentConnection() {
 	}
 }
 
-func (pso *PSO) Connect() (bool, string, error) {
-	server := constants.UnseenServerName
-	hwnd := w32.FindWindowW(nil, syscall.StringToUTF16Ptr(unseenWindowName))
-	if hwnd == 0 {
-		server = constants.EphineaServerName
-		// unseen not found
-		hwnd = w32.FindWindowW(nil, syscall.StringToUTF16Ptr(ephineaWindowName))
-		if hwnd == 0 {
-			return false, "Window not found", nil
-		}
+func (pso *PSO) Close() {
+	if pso.process != nil {
+		pso.process.Close()
+		pso.process = nil
 	}
+	pso.recorder = nil
+}
+
+// RecordTraces writes every memory read to a new trace file in dir each time we connect to pso
+func (pso *PSO) RecordTraces(dir string) {
+	pso.traceDir = dir
+}
 
-	_, pid := w32.GetWindowThreadProcessId(hwnd)
-	handle, err := w32.OpenProcess(w32.PROCESS_ALL_ACCESS, false, uintptr(pid))
+func (pso *PSO) startRecording() {
+	path := filepath.Join(pso.traceDir, fmt.Sprintf("trace-%v.psotrace", time.Now().Format("2006_01_02-150405")))
+	recorder, err := trace.Create(path, pso.process, pso.server)
 	if err != nil {
-		return false, "Could not open process", fmt.Errorf("Connect: could not open process with pid %v: %w", pid, err)
+		log.Printf("Unable to record trace to %v: %v", path, err)
+		return
 	}
+	log.Printf("Recording trace to %v", path)
+	pso.recorder = recorder
+	pso.process = recordingProcess{Recorder: recorder, process: pso.process}
+}
 
-	pso.handle = handle
-	pso.server = server
+type recordingProcess struct {
+	*trace.Recorder
+	process Process
+}
 
-	return true, fmt.Sprintf("Connected to pid %v", pid), nil
+func (p recordingProcess) Running() bool {
+	return p.process.Running()
 }
 
-func (pso *PSO) Close() {
-	w32.CloseHandle(pso.handle)
+func (p recordingProcess) Close() {
+	if err := p.Recorder.Close(); err != nil {
+		log.Printf("Unable to finish writing trace: %v", err)
+	}
+	p.process.Close()
+}
+
+// Server is the pso server the connected client plays on, empty before the first connection
+func (pso *PSO) Server() string {
+	return pso.server
 }
 
 func (pso *PSO) CheckConnection() (bool, string) {
@@ -188,6 +267,5 @@ func (pso *PSO) CheckConnection() (bool, string) {
 }
 
 func (pso *PSO) checkConnection() bool {
-	code, err := w32.GetExitCodeProcess(pso.handle)
-	return err == nil && code == windowsCodeStillActive
+	return pso.process != nil && pso.process.Running()
 }
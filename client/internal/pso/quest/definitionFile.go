@@ -0,0 +1,117 @@
+package quest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefinitionFile holds quests defined outside the client, in yaml or json
+//
+//	quests:
+//	  - episode: 1
+//	    name: My Event Quest
+//	    number: 9001
+//	    start: {register: 0}
+//	    end: {floor: 11, switch: 4}
+//	    splits:
+//	      - {name: Caves, trigger: {floor: 3, switch: 12}}
+type DefinitionFile struct {
+	Quests []Quest `yaml:"quests"`
+}
+
+func ReadDefinitionFile(path string) (DefinitionFile, error) {
+	definitions := DefinitionFile{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return definitions, err
+	}
+	// json is close enough to yaml that one parser reads both
+	err = yaml.UnmarshalStrict(data, &definitions)
+	return definitions, err
+}
+
+func (q *Quests) merge(definitionFile string) {
+	source := filepath.Base(definitionFile)
+	definitions, err := ReadDefinitionFile(definitionFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		q.warn("%v: %v", source, err)
+		return
+	}
+	for i, quest := range definitions.Quests {
+		if err := quest.validate(); err != nil {
+			q.warn("%v: quest %v '%v' skipped, %v", source, i+1, quest.Name, err)
+			continue
+		}
+		if quest.Number > 0 {
+			if existing, exists := q.questsById[questKey{episode: quest.Episode, number: quest.Number}]; exists {
+				q.warn("%v: episode %v #%v '%v' replaces '%v'", source, quest.Episode, quest.Number, quest.Name, existing.Name)
+				// a renamed quest shouldn't still be found under its old name
+				if byName, found := q.allQuests[quest.Episode][existing.Name]; found && existing.Name != quest.Name && byName.Number == quest.Number {
+					delete(q.allQuests[quest.Episode], existing.Name)
+				}
+			}
+		} else if _, exists := q.allQuests[quest.Episode][quest.Name]; exists {
+			q.warn("%v: episode %v '%v' replaces the existing definition", source, quest.Episode, quest.Name)
+		}
+		q.add(quest)
+	}
+}
+
+func (q *Quests) checkRemaps() {
+	for episode, questsForEpisode := range q.allQuests {
+		for name, quest := range questsForEpisode {
+			if quest.Remap == nil {
+				continue
+			}
+			if _, exists := questsForEpisode[*quest.Remap]; !exists {
+				q.warn("episode %v '%v' remaps to '%v' which isn't defined", episode, name, *quest.Remap)
+			}
+		}
+	}
+}
+
+func (q *Quests) warn(format string, args ...interface{}) {
+	q.warnings = append(q.warnings, fmt.Sprintf(format, args...))
+}
+
+func (q *Quest) validate() error {
+	if len(q.Name) == 0 {
+		return errors.New("name is required")
+	}
+	if q.Episode != 1 && q.Episode != 2 && q.Episode != 4 {
+		return fmt.Errorf("episode must be 1, 2 or 4 but was %v", q.Episode)
+	}
+	if q.Ignore || q.Remap != nil {
+		return nil
+	}
+	if q.CmodeStage < 0 {
+		return fmt.Errorf("cmodeStage can't be negative")
+	}
+	if err := q.Start.validate(); err != nil {
+		return fmt.Errorf("start %w", err)
+	}
+	if err := q.End.validate(); err != nil {
+		return fmt.Errorf("end %w", err)
+	}
+	if !q.End.IsSet() {
+		return errors.New("end needs a trigger")
+	}
+	for i, split := range q.Splits {
+		if len(split.Name) == 0 {
+			return fmt.Errorf("split %v needs a name", i+1)
+		}
+		if err := split.Trigger.validate(); err != nil {
+			return fmt.Errorf("split '%v' %w", split.Name, err)
+		}
+		if !split.Trigger.IsSet() {
+			return fmt.Errorf("split '%v' needs a trigger", split.Name)
+		}
+	}
+	return nil
+}
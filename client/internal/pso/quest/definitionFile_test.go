@@ -0,0 +1,151 @@
+package quest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso/quest"
+)
+
+func writeDefinitions(name string, contents string, t *testing.T) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assertWarning(expected string, quests quest.Quests, t *testing.T) {
+	for _, warning := range quests.Warnings() {
+		if strings.Contains(warning, expected) {
+			return
+		}
+	}
+	t.Errorf("Expected a warning containing '%v' but got %v", expected, quests.Warnings())
+}
+
+func TestNewQuests_BuiltInOnly(t *testing.T) {
+	quests := quest.NewQuests(filepath.Join(t.TempDir(), "quests.yaml"))
+	if len(quests.Warnings()) != 0 {
+		t.Errorf("Expected no warnings but got %v", quests.Warnings())
+	}
+	if _, found := quests.GetQuestConfig(101, 1, ""); !found {
+		t.Error("Expected Mop-up Operation #1 to be built in")
+	}
+}
+
+func TestNewQuests_MergesYaml(t *testing.T) {
+	path := writeDefinitions("quests.yaml", `
+quests:
+  - episode: 1
+    name: Event Quest
+    number: 9001
+    start: {register: 0}
+    end: {floor: 11, switch: 4}
+    forceTerminal: true
+    cmodeStage: 2
+    splits:
+      - {name: Caves, trigger: {floor: 3, switch: 12}}
+      - {name: Mines, trigger: {enteredFloor: 6}}
+      - {name: Boss, trigger: {any: [{waveCleared: true}, {register: 50}]}}
+  - episode: 1
+    name: Event Quest (Short)
+    remap: Event Quest
+`, t)
+	quests := quest.NewQuests(path)
+	if len(quests.Warnings()) != 0 {
+		t.Errorf("Expected no warnings but got %v", quests.Warnings())
+	}
+	config, found := quests.GetQuestConfig(9001, 1, "")
+	if !found || config.Name != "Event Quest" {
+		t.Fatalf("Expected Event Quest but got %v %v", config.Name, found)
+	}
+	if !config.StartsOnRegister() || *config.Start.Register != 0 || config.End.Floor != 11 || config.End.Switch != 4 {
+		t.Errorf("Unexpected triggers %+v %+v", config.Start, config.End)
+	}
+	if !config.ForceTerminal || config.GetCmodeStage() != 2 || len(config.Splits) != 3 || config.Splits[0].Trigger.Switch != 12 {
+		t.Errorf("Unexpected quest %+v", config)
+	}
+	remapped, found := quests.GetQuestConfig(0, 1, "Event Quest (Short)")
+	if !found || remapped.Name != "Event Quest" {
+		t.Errorf("Expected remap to Event Quest but got %v %v", remapped.Name, found)
+	}
+}
+
+func TestNewQuests_MergesJson(t *testing.T) {
+	path := writeDefinitions("quests.json", `{"quests": [
+		{"episode": 2, "name": "Json Quest", "number": 9002, "start": {"warpIn": true}, "end": {"register": 254}}
+	]}`, t)
+	quests := quest.NewQuests(path)
+	config, found := quests.GetQuestConfig(9002, 2, "")
+	if !found || !config.StartsAtWarpIn() || !config.EndsOnRegister() {
+		t.Errorf("Expected Json Quest starting at warp in but got %+v %v", config, found)
+	}
+}
+
+func TestNewQuests_CollisionReportsWinner(t *testing.T) {
+	first := writeDefinitions("quests.yaml", `
+quests:
+  - {episode: 1, name: My Mop-up, number: 101, start: {register: 0}, end: {register: 254}}
+`, t)
+	second := writeDefinitions("quests.json", `{"quests": [
+		{"episode": 1, "name": "Their Mop-up", "number": 101, "start": {"register": 0}, "end": {"register": 254}}
+	]}`, t)
+	quests := quest.NewQuests(first, second)
+	assertWarning("quests.yaml: episode 1 #101 'My Mop-up' replaces 'Mop-up Operation #1'", quests, t)
+	assertWarning("quests.json: episode 1 #101 'Their Mop-up' replaces 'My Mop-up'", quests, t)
+	config, _ := quests.GetQuestConfig(101, 1, "")
+	if config.Name != "Their Mop-up" {
+		t.Errorf("Expected the last file to win but got %v", config.Name)
+	}
+	if other, _ := quests.GetQuestConfig(101, 2, "Phantasmal World #1"); other.Name == "Their Mop-up" {
+		t.Error("Collisions should only replace quests in the same episode")
+	}
+	for _, replaced := range []string{"Mop-up Operation #1", "My Mop-up"} {
+		if _, found := quests.GetQuestConfig(0, 1, replaced); found {
+			t.Errorf("Expected '%v' to be removed when its number was replaced", replaced)
+		}
+	}
+}
+
+func TestNewQuests_ValidationErrors(t *testing.T) {
+	path := writeDefinitions("quests.yaml", `
+quests:
+  - {episode: 3, name: Bad Episode, start: {register: 0}, end: {register: 254}}
+  - {episode: 1, start: {register: 0}, end: {register: 254}}
+  - {episode: 1, name: Mixed Start, start: {register: 0, warpIn: true}, end: {register: 254}}
+  - {episode: 1, name: No End, start: {register: 0}}
+  - {episode: 1, name: Deep Floor, start: {floor: 40, switch: 1}, end: {register: 254}}
+  - {episode: 1, name: Untriggered Split, start: {register: 0}, end: {register: 254}, splits: [{name: Boss, trigger: {}}]}
+  - {episode: 1, name: Dangling, remap: Nowhere}
+  - {episode: 1, name: Good, number: 9003, start: {register: 0}, end: {register: 254}}
+`, t)
+	quests := quest.NewQuests(path)
+	assertWarning("quest 1 'Bad Episode' skipped, episode must be 1, 2 or 4", quests, t)
+	assertWarning("quest 2 '' skipped, name is required", quests, t)
+	assertWarning("'Mixed Start' skipped, start trigger must be exactly one of", quests, t)
+	assertWarning("'No End' skipped, end needs a trigger", quests, t)
+	assertWarning("'Deep Floor' skipped, start trigger floor must be at most 17", quests, t)
+	assertWarning("'Untriggered Split' skipped, split 'Boss' needs a trigger", quests, t)
+	assertWarning("'Dangling' remaps to 'Nowhere' which isn't defined", quests, t)
+	if _, found := quests.GetQuestConfig(0, 1, "No End"); found {
+		t.Error("Invalid quests should be left out")
+	}
+	if _, found := quests.GetQuestConfig(9003, 1, ""); !found {
+		t.Error("Valid quests should still load next to invalid ones")
+	}
+}
+
+func TestNewQuests_UnknownField(t *testing.T) {
+	path := writeDefinitions("quests.yaml", `
+quests:
+  - {episode: 1, name: Typo, strat: {register: 0}, end: {register: 254}}
+`, t)
+	quests := quest.NewQuests(path)
+	assertWarning("quests.yaml: ", quests, t)
+	if _, found := quests.GetQuestConfig(0, 1, "Typo"); found {
+		t.Error("A file that doesn't parse should be left out")
+	}
+}
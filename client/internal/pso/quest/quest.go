@@ -1,110 +1,112 @@
 package quest
 
 import (
-	"github.com/TheTitanrain/w32"
 	"github.com/phelix-/psostats/v2/client/internal/numbers"
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
 	"log"
 )
 
-func GetQuestPointer(handle w32.HANDLE) uintptr {
-	return uintptr(numbers.ReadU32Unchecked(handle, uintptr(0x00A95AA8)))
+func GetQuestPointer(memory numbers.MemoryReader, profile offsets.Profile) uintptr {
+	return uintptr(numbers.ReadU32Unchecked(memory, profile.Addresses.QuestPointer))
 }
 
-func GetQuestDataPointer(handle w32.HANDLE, questPtr uintptr) uintptr {
-	return uintptr(numbers.ReadU32Unchecked(handle, questPtr+0x19C))
+func GetQuestDataPointer(memory numbers.MemoryReader, profile offsets.Profile, questPtr uintptr) uintptr {
+	return uintptr(numbers.ReadU32Unchecked(memory, questPtr+profile.Quest.DataPointer))
 }
 
-func GetQuestRegisterPointer(handle w32.HANDLE, questPtr uintptr) uintptr {
-	return uintptr(numbers.ReadU32Unchecked(handle, questPtr+0x2C))
+func GetQuestRegisterPointer(memory numbers.MemoryReader, profile offsets.Profile, questPtr uintptr) uintptr {
+	return uintptr(numbers.ReadU32Unchecked(memory, questPtr+profile.Quest.RegisterPointer))
 }
 
-func IsRegisterSet(handle w32.HANDLE, registerId uint16, questRegisterAddress uintptr) bool {
-	value := GetRegisterValue(handle, registerId, questRegisterAddress)
+func IsRegisterSet(memory numbers.MemoryReader, registerId uint16, questRegisterAddress uintptr) bool {
+	value := GetRegisterValue(memory, registerId, questRegisterAddress)
 	return value > 0
 }
 
-func GetRegisterValue(handle w32.HANDLE, registerId uint16, questRegisterAddress uintptr) uint16 {
+func GetRegisterValue(memory numbers.MemoryReader, registerId uint16, questRegisterAddress uintptr) uint16 {
 	value := uint16(0)
 	if questRegisterAddress != 0 {
-		return numbers.ReadU16(handle, questRegisterAddress+(4*uintptr(registerId)))
+		return numbers.ReadU16(memory, questRegisterAddress+(4*uintptr(registerId)))
 	}
 	return value
 }
 
-func warpIn() Trigger {
-	return Trigger{WarpIn: true}
-}
-
-func register(register int) Trigger {
-	registerU16 := uint16(register)
-	return Trigger{Register: &registerU16}
-}
-
-func floorSwitch(floor int, switchId int) Trigger {
-	return Trigger{Floor: uint16(floor), Switch: uint16(switchId)}
-}
-
 func remap(questName string) *string {
 	return &questName
 }
 
-type Trigger struct {
-	Register *uint16
-	Floor    uint16
-	Switch   uint16
-	WarpIn   bool
-}
-
 type Quest struct {
-	Episode       int
-	Name          string
-	Number        uint16
-	Ignore        bool
-	ForceTerminal bool
-	Remap         *string
-	CmodeStage    int
-	Start         Trigger
-	End           Trigger
-	Splits        []Split
+	Episode       int     `yaml:"episode"`
+	Name          string  `yaml:"name"`
+	Number        uint16  `yaml:"number"`
+	Ignore        bool    `yaml:"ignore"`
+	ForceTerminal bool    `yaml:"forceTerminal"`
+	Remap         *string `yaml:"remap"`
+	CmodeStage    int     `yaml:"cmodeStage"`
+	Start         Trigger `yaml:"start"`
+	End           Trigger `yaml:"end"`
+	Splits        []Split `yaml:"splits"`
 }
 
 type Split struct {
-	Name    string
-	Trigger Trigger
+	Name    string  `yaml:"name"`
+	Trigger Trigger `yaml:"trigger"`
+}
+
+type questKey struct {
+	episode int
+	number  uint16
 }
 
 type Quests struct {
-	questsById   map[uint16]Quest
+	questsById   map[questKey]Quest
 	allQuests    map[int]map[string]Quest
 	warnedQuests map[string]bool
+	warnings     []string
 }
 
-func NewQuests() Quests {
-	questsById := make(map[uint16]Quest)
-	allQuests := make(map[int]map[string]Quest)
-	unsortedQuests := GetAllQuests()
-
-	for _, quest := range unsortedQuests {
-		questsForEpisode := allQuests[quest.Episode]
-		if questsForEpisode == nil {
-			questsForEpisode = make(map[string]Quest)
-		}
-		questsForEpisode[quest.Name] = quest
-		allQuests[quest.Episode] = questsForEpisode
-		if quest.Number > 0 {
-			questsById[quest.Number] = quest
-		}
+// NewQuests loads the built in quests, then merges in definitionFiles in order. Later definitions replace earlier
+// ones with the same episode and number or name. Missing files are skipped, problems with the others are kept
+// for Warnings and the offending quest or file is left out.
+func NewQuests(definitionFiles ...string) Quests {
+	quests := Quests{
+		questsById:   make(map[questKey]Quest),
+		allQuests:    make(map[int]map[string]Quest),
+		warnedQuests: make(map[string]bool),
+		warnings:     make([]string, 0),
+	}
+	for _, quest := range GetAllQuests() {
+		quests.add(quest)
+	}
+	for _, definitionFile := range definitionFiles {
+		quests.merge(definitionFile)
+	}
+	quests.checkRemaps()
+	for _, warning := range quests.warnings {
+		log.Printf("Quest definitions: %v", warning)
 	}
+	return quests
+}
 
-	return Quests{
-		questsById:   questsById,
-		allQuests:    allQuests,
-		warnedQuests: make(map[string]bool),
+func (q *Quests) add(quest Quest) {
+	questsForEpisode := q.allQuests[quest.Episode]
+	if questsForEpisode == nil {
+		questsForEpisode = make(map[string]Quest)
+	}
+	questsForEpisode[quest.Name] = quest
+	q.allQuests[quest.Episode] = questsForEpisode
+	if quest.Number > 0 {
+		q.questsById[questKey{episode: quest.Episode, number: quest.Number}] = quest
 	}
 }
 
+// Warnings are the problems found while merging quest definition files
+func (q *Quests) Warnings() []string {
+	return q.warnings
+}
+
 func (q *Quests) GetQuestConfig(questNumber uint16, episode int, questName string) (Quest, bool) {
-	quest, questFound := q.questsById[questNumber]
+	quest, questFound := q.questsById[questKey{episode: episode, number: questNumber}]
 	if !questFound {
 		questsForEpisode, exists := q.allQuests[episode]
 		if !exists {
@@ -126,11 +128,11 @@ func (q *Quests) GetQuestConfig(questNumber uint16, episode int, questName strin
 }
 
 func (q *Quest) StartsOnRegister() bool {
-	return q.Start.Register != nil
+	return q.Start.uses(func(t Trigger) bool { return t.Register != nil })
 }
 
 func (q *Quest) StartsAtWarpIn() bool {
-	return q.Start.WarpIn
+	return q.Start.uses(func(t Trigger) bool { return t.WarpIn })
 }
 
 func (q *Quest) TerminalQuest() bool {
@@ -138,7 +140,7 @@ func (q *Quest) TerminalQuest() bool {
 }
 
 func (q *Quest) EndsOnRegister() bool {
-	return q.End.Register != nil
+	return q.End.uses(func(t Trigger) bool { return t.Register != nil })
 }
 
 func (q *Quest) GetCmodeStage() int {
@@ -0,0 +1,204 @@
+package quest
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	maxFloor  = 17
+	maxSwitch = 255
+)
+
+// Trigger is a condition tree. Each trigger is exactly one of:
+//   - a register comparison, a bare register means register > 0
+//   - a warp in, any player has left pioneer 2
+//   - a monster killed during the quest, by unitxt id
+//   - the current floor being enteredFloor
+//   - a wave cleared, monsters spawned since the current split started and all of them are dead
+//   - all, any or not of other triggers
+//   - a floor switch, when none of the above are set
+type Trigger struct {
+	Register     *uint16   `yaml:"register"`
+	Compare      string    `yaml:"compare"`
+	Value        uint16    `yaml:"value"`
+	Floor        uint16    `yaml:"floor"`
+	Switch       uint16    `yaml:"switch"`
+	WarpIn       bool      `yaml:"warpIn"`
+	Killed       *uint32   `yaml:"killed"`
+	EnteredFloor *uint16   `yaml:"enteredFloor"`
+	WaveCleared  bool      `yaml:"waveCleared"`
+	All          []Trigger `yaml:"all"`
+	Any          []Trigger `yaml:"any"`
+	Not          *Trigger  `yaml:"not"`
+}
+
+// TriggerState is the game a trigger is evaluated against
+type TriggerState interface {
+	RegisterValue(register uint16) uint16
+	FloorSwitch(floor uint16, switchId uint16) (bool, error)
+	WarpedIn() (bool, error)
+	MonsterKilled(unitxtId uint32) bool
+	CurrentFloor() uint16
+	WaveCleared() bool
+}
+
+func warpIn() Trigger {
+	return Trigger{WarpIn: true}
+}
+
+func register(register int) Trigger {
+	registerU16 := uint16(register)
+	return Trigger{Register: &registerU16}
+}
+
+func registerCompare(register int, compare string, value int) Trigger {
+	registerU16 := uint16(register)
+	return Trigger{Register: &registerU16, Compare: compare, Value: uint16(value)}
+}
+
+func floorSwitch(floor int, switchId int) Trigger {
+	return Trigger{Floor: uint16(floor), Switch: uint16(switchId)}
+}
+
+func killed(unitxtId int) Trigger {
+	unitxtU32 := uint32(unitxtId)
+	return Trigger{Killed: &unitxtU32}
+}
+
+func enteredFloor(floor int) Trigger {
+	floorU16 := uint16(floor)
+	return Trigger{EnteredFloor: &floorU16}
+}
+
+func waveCleared() Trigger {
+	return Trigger{WaveCleared: true}
+}
+
+func allOf(triggers ...Trigger) Trigger {
+	return Trigger{All: triggers}
+}
+
+func anyOf(triggers ...Trigger) Trigger {
+	return Trigger{Any: triggers}
+}
+
+func not(trigger Trigger) Trigger {
+	return Trigger{Not: &trigger}
+}
+
+// IsSet is false for the zero trigger, which would otherwise be switch 0 on pioneer 2
+func (t Trigger) IsSet() bool {
+	return t.kinds() > 0 || t.Floor != 0 || t.Switch != 0
+}
+
+func (t Trigger) Evaluate(state TriggerState) (bool, error) {
+	switch {
+	case t.Register != nil:
+		return compare(state.RegisterValue(*t.Register), t.Compare, t.Value)
+	case t.WarpIn:
+		return state.WarpedIn()
+	case t.Killed != nil:
+		return state.MonsterKilled(*t.Killed), nil
+	case t.EnteredFloor != nil:
+		return state.CurrentFloor() == *t.EnteredFloor, nil
+	case t.WaveCleared:
+		return state.WaveCleared(), nil
+	case len(t.All) > 0:
+		for _, trigger := range t.All {
+			met, err := trigger.Evaluate(state)
+			if err != nil || !met {
+				return false, err
+			}
+		}
+		return true, nil
+	case len(t.Any) > 0:
+		for _, trigger := range t.Any {
+			met, err := trigger.Evaluate(state)
+			if err != nil || met {
+				return met, err
+			}
+		}
+		return false, nil
+	case t.Not != nil:
+		met, err := t.Not.Evaluate(state)
+		return !met && err == nil, err
+	default:
+		return state.FloorSwitch(t.Floor, t.Switch)
+	}
+}
+
+func compare(actual uint16, comparison string, value uint16) (bool, error) {
+	switch comparison {
+	case "", ">":
+		return actual > value, nil
+	case ">=":
+		return actual >= value, nil
+	case "==":
+		return actual == value, nil
+	case "!=":
+		return actual != value, nil
+	case "<":
+		return actual < value, nil
+	case "<=":
+		return actual <= value, nil
+	default:
+		return false, fmt.Errorf("unknown comparison '%v'", comparison)
+	}
+}
+
+// uses reports whether this trigger or any trigger under it matches
+func (t Trigger) uses(matches func(Trigger) bool) bool {
+	if matches(t) {
+		return true
+	}
+	for _, trigger := range t.children() {
+		if trigger.uses(matches) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Trigger) children() []Trigger {
+	children := make([]Trigger, 0, len(t.All)+len(t.Any)+1)
+	children = append(children, t.All...)
+	children = append(children, t.Any...)
+	if t.Not != nil {
+		children = append(children, *t.Not)
+	}
+	return children
+}
+
+// kinds counts how many of the non floor switch conditions are set
+func (t Trigger) kinds() int {
+	kinds := 0
+	for _, set := range []bool{t.Register != nil, t.WarpIn, t.Killed != nil, t.EnteredFloor != nil,
+		t.WaveCleared, len(t.All) > 0, len(t.Any) > 0, t.Not != nil} {
+		if set {
+			kinds++
+		}
+	}
+	return kinds
+}
+
+func (t Trigger) validate() error {
+	if t.kinds() > 1 || (t.kinds() == 1 && (t.Floor != 0 || t.Switch != 0)) {
+		return errors.New("trigger must be exactly one of register, warpIn, killed, enteredFloor, waveCleared, all, any, not or a floor switch")
+	}
+	if t.Register == nil && (len(t.Compare) > 0 || t.Value != 0) {
+		return errors.New("trigger compare and value only apply to a register")
+	}
+	if _, err := compare(0, t.Compare, 0); err != nil {
+		return fmt.Errorf("trigger %w", err)
+	}
+	if t.Floor > maxFloor || t.Switch > maxSwitch || (t.EnteredFloor != nil && *t.EnteredFloor > maxFloor) {
+		return fmt.Errorf("trigger floor must be at most %v and switch at most %v", maxFloor, maxSwitch)
+	}
+	for _, trigger := range t.children() {
+		if err := trigger.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package quest
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeTriggerState struct {
+	registers map[uint16]uint16
+	switches  map[[2]uint16]bool
+	warpedIn  bool
+	killed    map[uint32]bool
+	floor     uint16
+	cleared   bool
+	err       error
+}
+
+func (s fakeTriggerState) RegisterValue(register uint16) uint16 {
+	return s.registers[register]
+}
+
+func (s fakeTriggerState) FloorSwitch(floor uint16, switchId uint16) (bool, error) {
+	return s.switches[[2]uint16{floor, switchId}], s.err
+}
+
+func (s fakeTriggerState) WarpedIn() (bool, error) {
+	return s.warpedIn, nil
+}
+
+func (s fakeTriggerState) MonsterKilled(unitxtId uint32) bool {
+	return s.killed[unitxtId]
+}
+
+func (s fakeTriggerState) CurrentFloor() uint16 {
+	return s.floor
+}
+
+func (s fakeTriggerState) WaveCleared() bool {
+	return s.cleared
+}
+
+func TestTrigger_Evaluate(t *testing.T) {
+	state := fakeTriggerState{
+		registers: map[uint16]uint16{0: 1, 51: 300, 52: 299},
+		switches:  map[[2]uint16]bool{{7, 1}: true},
+		killed:    map[uint32]bool{47: true},
+		floor:     11,
+		cleared:   true,
+	}
+	tests := []struct {
+		name    string
+		trigger Trigger
+		met     bool
+	}{
+		{"register set", register(0), true},
+		{"register unset", register(1), false},
+		{"register at least, equal", registerCompare(51, ">=", 300), true},
+		{"register at least, below", registerCompare(52, ">=", 300), false},
+		{"register greater than", registerCompare(51, ">", 300), false},
+		{"register equal", registerCompare(52, "==", 299), true},
+		{"register not equal", registerCompare(52, "!=", 299), false},
+		{"register less than", registerCompare(52, "<", 300), true},
+		{"register at most", registerCompare(51, "<=", 299), false},
+		{"floor switch set", floorSwitch(7, 1), true},
+		{"floor switch unset", floorSwitch(7, 2), false},
+		{"warp in", warpIn(), false},
+		{"boss killed", killed(47), true},
+		{"boss alive", killed(48), false},
+		{"entered floor", enteredFloor(11), true},
+		{"other floor", enteredFloor(10), false},
+		{"wave cleared", waveCleared(), true},
+		{"all met", allOf(register(0), killed(47), enteredFloor(11)), true},
+		{"all, one unmet", allOf(register(0), killed(48)), false},
+		{"any, one met", anyOf(killed(48), registerCompare(51, ">=", 300)), true},
+		{"any, none met", anyOf(killed(48), warpIn()), false},
+		{"not", not(killed(48)), true},
+		{"nested", anyOf(allOf(register(1), killed(47)), allOf(floorSwitch(7, 1), not(warpIn()))), true},
+	}
+	for _, test := range tests {
+		met, err := test.trigger.Evaluate(state)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", test.name, err)
+		} else if met != test.met {
+			t.Errorf("%v: expected %v but got %v", test.name, test.met, met)
+		}
+	}
+}
+
+func TestTrigger_EvaluateErrors(t *testing.T) {
+	state := fakeTriggerState{err: errors.New("unable to getFloorSwitches")}
+	tests := []struct {
+		name    string
+		trigger Trigger
+	}{
+		{"unknown comparison", registerCompare(0, "=>", 1)},
+		{"floor switch read fails", floorSwitch(1, 1)},
+		{"all", allOf(floorSwitch(1, 1))},
+		{"any", anyOf(killed(1), floorSwitch(1, 1))},
+		{"not", not(floorSwitch(1, 1))},
+	}
+	for _, test := range tests {
+		met, err := test.trigger.Evaluate(state)
+		if err == nil || met {
+			t.Errorf("%v: expected an error but got %v %v", test.name, met, err)
+		}
+	}
+}
+
+func TestTrigger_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger Trigger
+		valid   bool
+	}{
+		{"register", register(0), true},
+		{"comparison", registerCompare(51, ">=", 300), true},
+		{"floor switch", floorSwitch(17, 255), true},
+		{"nested", allOf(anyOf(killed(47), enteredFloor(11)), not(warpIn())), true},
+		{"floor too deep", floorSwitch(18, 0), false},
+		{"entered floor too deep", enteredFloor(18), false},
+		{"unknown comparison", registerCompare(51, "=>", 300), false},
+		{"comparison without register", Trigger{Compare: ">=", Value: 3}, false},
+		{"two kinds", Trigger{WarpIn: true, All: []Trigger{register(0)}}, false},
+		{"wave cleared with register", Trigger{WaveCleared: true, Register: register(0).Register}, false},
+		{"kind with floor switch", Trigger{Killed: killed(47).Killed, Floor: 3}, false},
+		{"invalid child", anyOf(killed(47), not(floorSwitch(20, 0))), false},
+	}
+	for _, test := range tests {
+		err := test.trigger.validate()
+		if (err == nil) != test.valid {
+			t.Errorf("%v: expected valid=%v but got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestQuest_StartKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		quest      Quest
+		onRegister bool
+		atWarpIn   bool
+		terminal   bool
+	}{
+		{"register", Quest{Start: register(0)}, true, false, false},
+		{"warp in", Quest{Start: warpIn()}, false, true, false},
+		{"floor switch", Quest{Start: floorSwitch(7, 1)}, false, false, true},
+		{"forced terminal", Quest{Start: register(5), ForceTerminal: true}, true, false, true},
+		{"nested register", Quest{Start: allOf(enteredFloor(1), registerCompare(51, ">=", 1))}, true, false, false},
+		{"killed", Quest{Start: killed(47)}, false, false, true},
+	}
+	for _, test := range tests {
+		if test.quest.StartsOnRegister() != test.onRegister || test.quest.StartsAtWarpIn() != test.atWarpIn ||
+			test.quest.TerminalQuest() != test.terminal {
+			t.Errorf("%v: expected register=%v warpIn=%v terminal=%v", test.name, test.onRegister, test.atWarpIn, test.terminal)
+		}
+	}
+}
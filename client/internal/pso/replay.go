@@ -0,0 +1,53 @@
+package pso
+
+import (
+	"fmt"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
+	"github.com/phelix-/psostats/v2/client/internal/trace"
+)
+
+// ReplayTrace feeds a recorded trace back through RefreshData tick by tick and returns every quest run
+// completed during it. The trace supplies both memory and the clock, so replays are deterministic.
+// profiles and build pick memory offsets the same way the client does, so replay with the offsets the trace was recorded with.
+func ReplayTrace(path string, profiles offsets.Profiles, build string, questDefinitionFiles ...string) ([]QuestRun, error) {
+	replay, err := trace.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer replay.Close()
+
+	// RefreshData sends at most one started and one completed quest per tick
+	startedGame := make(chan QuestRun, 1)
+	completeGame := make(chan QuestRun, 1)
+	pso := New(startedGame, completeGame)
+	pso.SetOffsetProfiles(profiles, build)
+	pso.LoadQuestDefinitions(questDefinitionFiles...)
+	if err := pso.attach(replay, replay.Server()); err != nil {
+		return nil, fmt.Errorf("ReplayTrace: %w", err)
+	}
+	pso.connected = true
+
+	runs := make([]QuestRun, 0)
+	for tick := 0; ; tick++ {
+		tickTime, ok, err := replay.NextTick()
+		if err != nil {
+			return runs, fmt.Errorf("ReplayTrace: tick %v: %w", tick, err)
+		} else if !ok {
+			break
+		}
+		if err := pso.refreshDataAt(tickTime); err != nil {
+			return runs, fmt.Errorf("ReplayTrace: tick %v: %w", tick, err)
+		}
+		select {
+		case <-startedGame:
+		default:
+		}
+		select {
+		case run := <-completeGame:
+			runs = append(runs, run)
+		default:
+		}
+	}
+	return runs, nil
+}
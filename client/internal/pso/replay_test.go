@@ -0,0 +1,75 @@
+package pso
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso/offsets"
+	"github.com/phelix-/psostats/v2/client/internal/trace"
+)
+
+func TestReplayTrace_ReproducesQuestRun(t *testing.T) {
+	pso, memory := newSyntheticPso()
+	tracePath := filepath.Join(t.TempDir(), "test.psotrace")
+	recorder, err := trace.Create(tracePath, pso.process, pso.server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pso.recorder = recorder
+	pso.process = recordingProcess{Recorder: recorder, process: pso.process}
+
+	tickTime := time.Unix(1600000000, 0)
+	var recorded []QuestRun
+	tick := func() {
+		tickTime = tickTime.Add(persistentConnectionTickRate)
+		if err := pso.recorder.StartTick(tickTime); err != nil {
+			t.Fatal(err)
+		}
+		if err := pso.refreshDataAt(tickTime); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-pso.startedGame:
+		default:
+		}
+		select {
+		case run := <-pso.completeGame:
+			recorded = append(recorded, run)
+		default:
+		}
+	}
+
+	tick()
+	loadQuest(memory, 101, "Mop-up Operation #1")
+	tick()
+	setRegister(memory, 0, 1)
+	for i := 0; i < 45; i++ {
+		if i == 20 {
+			memory.WriteU16(testPlayerAddress+0x334, 1500)
+		}
+		tick()
+	}
+	setRegister(memory, 254, 1)
+	tick()
+	tick()
+	pso.Close()
+
+	if len(recorded) != 1 {
+		t.Fatalf("Expected 1 recorded run but got %v", len(recorded))
+	}
+	replayed, err := ReplayTrace(tracePath, offsets.Default(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 1 {
+		t.Fatalf("Expected 1 replayed run but got %v", len(replayed))
+	}
+	if !reflect.DeepEqual(recorded[0], replayed[0]) {
+		t.Errorf("Replayed run differs from recorded run\nrecorded: %+v\nreplayed: %+v", recorded[0], replayed[0])
+	}
+	if replayed[0].HP[len(replayed[0].HP)-1] != 1500 {
+		t.Errorf("Expected replayed HP to end at 1500 but got %v", replayed[0].HP)
+	}
+}
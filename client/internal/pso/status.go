@@ -0,0 +1,80 @@
+package pso
+
+import (
+	"time"
+
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+// How long after a trap is set that a monster freezing or getting confused is put down to it
+const trapEffectWindow = 3 * time.Second
+
+// useTraps records the freeze and confuse traps the player set since the last tick
+func (pso *PSO) useTraps(questRun *QuestRun) {
+	second := int(pso.tickTime.Sub(questRun.QuestStartTime).Seconds())
+	freezeTraps := pso.CurrentPlayerData.FreezeTraps
+	for used := questRun.previousTickFt; used > freezeTraps; used-- {
+		questRun.TrapUses = append(questRun.TrapUses, model.TrapUse{Second: second, Time: pso.tickTime, Trap: model.TrapFreeze})
+		questRun.StatusEffects.FreezeTrapsUsed++
+	}
+	questRun.previousTickFt = freezeTraps
+	confuseTraps := pso.CurrentPlayerData.ConfuseTraps
+	for used := questRun.previousTickCt; used > confuseTraps; used-- {
+		questRun.TrapUses = append(questRun.TrapUses, model.TrapUse{Second: second, Time: pso.tickTime, Trap: model.TrapConfuse})
+		questRun.StatusEffects.ConfuseTrapsUsed++
+	}
+	questRun.previousTickCt = confuseTraps
+}
+
+// applyStatusEffects adds elapsed to each status the monster had on the last tick, and counts the ones it just got.
+// New freezes and confusions are put down to the player's latest trap of that kind if it was set recently enough.
+func (questRun *QuestRun) applyStatusEffects(monster *Monster, current model.MonsterLocation, now time.Time, elapsed time.Duration) {
+	previous := monster.Location
+	effects := &questRun.StatusEffects
+	if previous.Frozen {
+		monster.FrozenTime += elapsed
+		effects.FrozenTime += elapsed
+	}
+	if previous.Confused {
+		monster.ConfusedTime += elapsed
+		effects.ConfusedTime += elapsed
+	}
+	if previous.Paralyzed {
+		monster.ParalyzedTime += elapsed
+		effects.ParalyzedTime += elapsed
+	}
+	if current.Frozen && !previous.Frozen {
+		monster.Freezes++
+		effects.Freezes++
+		if questRun.creditTrap(model.TrapFreeze, now) {
+			effects.FrozenByTraps++
+		}
+	}
+	if current.Confused && !previous.Confused {
+		monster.Confusions++
+		effects.Confusions++
+		if questRun.creditTrap(model.TrapConfuse, now) {
+			effects.ConfusedByTraps++
+		}
+	}
+	if current.Paralyzed && !previous.Paralyzed {
+		monster.Paralyses++
+		effects.Paralyses++
+	}
+	monster.Location = current
+}
+
+// creditTrap counts a monster against the latest trap of kind set within trapEffectWindow of now
+func (questRun *QuestRun) creditTrap(trap string, now time.Time) bool {
+	for i := len(questRun.TrapUses) - 1; i >= 0; i-- {
+		trapUse := &questRun.TrapUses[i]
+		if now.Sub(trapUse.Time) > trapEffectWindow {
+			return false
+		}
+		if trapUse.Trap == trap {
+			trapUse.MonstersAffected++
+			return true
+		}
+	}
+	return false
+}
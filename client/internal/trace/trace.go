@@ -0,0 +1,337 @@
+// Records every pso memory read made during a tick so quest runs can be replayed offline
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+)
+
+// File layout, gzipped:
+//
+//	header: magic, version, server name
+//	ticks:  'T', unix nanos, read count, reads
+//	read:   address, size, kind, [data when kind is readData]
+//
+// Reads that return the same bytes as the previous read of that address and size are stored as readRepeated.
+const (
+	magic   = "PSOTRC"
+	version = byte(1)
+
+	tickMarker   = byte('T')
+	readData     = byte(0)
+	readRepeated = byte(1)
+	readFailed   = byte(2)
+)
+
+type readKey struct {
+	address uintptr
+	size    uintptr
+}
+
+// Recorder wraps a MemoryReader and writes every read it serves to a trace file
+type Recorder struct {
+	memory   numbers.MemoryReader
+	file     io.WriteCloser
+	gzip     *gzip.Writer
+	tickTime time.Time
+	tick     bytes.Buffer
+	reads    int
+	previous map[readKey][]uint16
+}
+
+func Create(path string, memory numbers.MemoryReader, server string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(file, memory, server)
+}
+
+func NewRecorder(file io.WriteCloser, memory numbers.MemoryReader, server string) (*Recorder, error) {
+	gzipWriter := gzip.NewWriter(file)
+	header := bytes.NewBufferString(magic)
+	header.WriteByte(version)
+	writeString(header, server)
+	if _, err := gzipWriter.Write(header.Bytes()); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Recorder{
+		memory:   memory,
+		file:     file,
+		gzip:     gzipWriter,
+		previous: make(map[readKey][]uint16),
+	}, nil
+}
+
+func (r *Recorder) ReadMemory(address uintptr, size uintptr) ([]uint16, bool) {
+	buf, ok := r.memory.ReadMemory(address, size)
+	r.reads++
+	writeUvarint(&r.tick, uint64(address))
+	writeUvarint(&r.tick, uint64(size))
+	key := readKey{address: address, size: size}
+	if !ok {
+		r.tick.WriteByte(readFailed)
+		delete(r.previous, key)
+		return buf, ok
+	}
+	data := buf[:dataWords(size)]
+	if previous, exists := r.previous[key]; exists && wordsEqual(previous, data) {
+		r.tick.WriteByte(readRepeated)
+	} else {
+		r.tick.WriteByte(readData)
+		words := make([]byte, 2)
+		for _, word := range data {
+			binary.LittleEndian.PutUint16(words, word)
+			r.tick.Write(words)
+		}
+		r.previous[key] = append([]uint16(nil), data...)
+	}
+	return buf, ok
+}
+
+// StartTick writes out the reads of the previous tick and starts recording a new one
+func (r *Recorder) StartTick(tickTime time.Time) error {
+	if err := r.flushTick(); err != nil {
+		return err
+	}
+	r.tickTime = tickTime
+	return nil
+}
+
+func (r *Recorder) flushTick() error {
+	if r.tickTime.IsZero() {
+		return nil
+	}
+	header := bytes.NewBuffer([]byte{tickMarker})
+	writeVarint(header, r.tickTime.UnixNano())
+	writeUvarint(header, uint64(r.reads))
+	if _, err := r.gzip.Write(header.Bytes()); err != nil {
+		return err
+	}
+	if _, err := r.gzip.Write(r.tick.Bytes()); err != nil {
+		return err
+	}
+	r.tick.Reset()
+	r.reads = 0
+	r.tickTime = time.Time{}
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	flushErr := r.flushTick()
+	gzipErr := r.gzip.Close()
+	fileErr := r.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	if gzipErr != nil {
+		return gzipErr
+	}
+	return fileErr
+}
+
+// Replay serves recorded reads back tick by tick, it satisfies the same interface as a live pso process
+type Replay struct {
+	file     io.Closer
+	reader   *bufio.Reader
+	server   string
+	tickTime time.Time
+	tick     map[readKey][][]uint16
+	previous map[readKey][]uint16
+}
+
+func Open(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	replay, err := NewReplay(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return replay, nil
+}
+
+func NewReplay(file io.ReadCloser) (*Replay, error) {
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("NewReplay: not a trace file: %w", err)
+	}
+	reader := bufio.NewReader(gzipReader)
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("NewReplay: reading header: %w", err)
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("NewReplay: not a trace file")
+	}
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("NewReplay: unsupported trace version %v", header[len(magic)])
+	}
+	server, err := readString(reader)
+	if err != nil {
+		return nil, fmt.Errorf("NewReplay: reading server: %w", err)
+	}
+	return &Replay{
+		file:     file,
+		reader:   reader,
+		server:   server,
+		tick:     make(map[readKey][][]uint16),
+		previous: make(map[readKey][]uint16),
+	}, nil
+}
+
+func (r *Replay) Server() string {
+	return r.server
+}
+
+// NextTick loads the next recorded tick, returning false once the trace is exhausted
+func (r *Replay) NextTick() (time.Time, bool, error) {
+	marker, err := r.reader.ReadByte()
+	if err == io.EOF {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		return time.Time{}, false, err
+	}
+	if marker != tickMarker {
+		return time.Time{}, false, fmt.Errorf("NextTick: unexpected marker 0x%02x", marker)
+	}
+	nanos, err := binary.ReadVarint(r.reader)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	reads, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	r.tick = make(map[readKey][][]uint16)
+	for i := uint64(0); i < reads; i++ {
+		if err := r.readRecordedRead(); err != nil {
+			return time.Time{}, false, fmt.Errorf("NextTick: read %v: %w", i, err)
+		}
+	}
+	r.tickTime = time.Unix(0, nanos)
+	return r.tickTime, true, nil
+}
+
+func (r *Replay) readRecordedRead() error {
+	address, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return err
+	}
+	size, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return err
+	}
+	kind, err := r.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	key := readKey{address: uintptr(address), size: uintptr(size)}
+	var data []uint16
+	switch kind {
+	case readData:
+		raw := make([]byte, 2*dataWords(key.size))
+		if _, err := io.ReadFull(r.reader, raw); err != nil {
+			return err
+		}
+		data = make([]uint16, len(raw)/2)
+		for i := range data {
+			data[i] = binary.LittleEndian.Uint16(raw[2*i:])
+		}
+		r.previous[key] = data
+	case readRepeated:
+		previous, exists := r.previous[key]
+		if !exists {
+			return fmt.Errorf("repeated read of 0x%08x with nothing before it", address)
+		}
+		data = previous
+	case readFailed:
+		delete(r.previous, key)
+	default:
+		return fmt.Errorf("unknown read kind %v", kind)
+	}
+	r.tick[key] = append(r.tick[key], data)
+	return nil
+}
+
+// ReadMemory serves reads in the order they were recorded during the current tick.
+// Reads the recording didn't see this tick fall back to the last value recorded for that address.
+func (r *Replay) ReadMemory(address uintptr, size uintptr) ([]uint16, bool) {
+	key := readKey{address: address, size: size}
+	var data []uint16
+	if queued := r.tick[key]; len(queued) > 0 {
+		data = queued[0]
+		r.tick[key] = queued[1:]
+	} else {
+		data = r.previous[key]
+	}
+	if data == nil {
+		return nil, false
+	}
+	buf := make([]uint16, size)
+	copy(buf, data)
+	return buf, true
+}
+
+func (r *Replay) Running() bool {
+	return true
+}
+
+func (r *Replay) Close() {
+	r.file.Close()
+}
+
+func dataWords(size uintptr) uintptr {
+	return (size + 1) / 2
+}
+
+func wordsEqual(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeUvarint(buf *bytes.Buffer, value uint64) {
+	varint := make([]byte, binary.MaxVarintLen64)
+	buf.Write(varint[:binary.PutUvarint(varint, value)])
+}
+
+func writeVarint(buf *bytes.Buffer, value int64) {
+	varint := make([]byte, binary.MaxVarintLen64)
+	buf.Write(varint[:binary.PutVarint(varint, value)])
+}
+
+func writeString(buf *bytes.Buffer, value string) {
+	writeUvarint(buf, uint64(len(value)))
+	buf.WriteString(value)
+}
+
+func readString(reader *bufio.Reader) (string, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return "", err
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
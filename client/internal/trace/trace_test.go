@@ -0,0 +1,73 @@
+package trace_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/numbers"
+	"github.com/phelix-/psostats/v2/client/internal/trace"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	memory := numbers.NewFakeMemory()
+	memory.WriteU32(0x1000, 0xCAFEF00D)
+	memory.WriteU8(0x2000, 7)
+	path := filepath.Join(t.TempDir(), "test.psotrace")
+	recorder, err := trace.Create(path, memory, "ephinea")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstTick := time.Unix(1600000000, 5)
+	recorder.StartTick(firstTick)
+	recorder.ReadMemory(0x1000, 4)
+	recorder.ReadMemory(0x2000, 1)
+	recorder.ReadMemory(0x9000, 4)
+	recorder.StartTick(firstTick.Add(time.Second))
+	recorder.ReadMemory(0x1000, 4)
+	memory.WriteU8(0x2000, 8)
+	recorder.ReadMemory(0x2000, 1)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := trace.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replay.Close()
+	if replay.Server() != "ephinea" {
+		t.Errorf("Expected server ephinea but got %v", replay.Server())
+	}
+
+	tickTime, ok, err := replay.NextTick()
+	if err != nil || !ok || !tickTime.Equal(firstTick) {
+		t.Fatalf("Unexpected first tick %v %v %v", tickTime, ok, err)
+	}
+	assertRead(replay, 0x1000, 4, []uint16{0xF00D, 0xCAFE, 0, 0}, t)
+	assertRead(replay, 0x2000, 1, []uint16{7}, t)
+	if _, ok := replay.ReadMemory(0x9000, 4); ok {
+		t.Error("Failed read was replayed as a success")
+	}
+
+	_, ok, err = replay.NextTick()
+	if err != nil || !ok {
+		t.Fatalf("Unexpected second tick %v %v", ok, err)
+	}
+	assertRead(replay, 0x1000, 4, []uint16{0xF00D, 0xCAFE, 0, 0}, t)
+	assertRead(replay, 0x2000, 1, []uint16{8}, t)
+
+	_, ok, err = replay.NextTick()
+	if err != nil || ok {
+		t.Errorf("Expected end of trace but got %v %v", ok, err)
+	}
+}
+
+func assertRead(replay *trace.Replay, address uintptr, size uintptr, expected []uint16, t *testing.T) {
+	buf, ok := replay.ReadMemory(address, size)
+	if !ok || !reflect.DeepEqual(expected, buf) {
+		t.Errorf("Read of 0x%08x expected %v but got %v (%v)", address, expected, buf, ok)
+	}
+}
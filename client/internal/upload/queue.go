@@ -0,0 +1,226 @@
+// Queues completed games on disk until the server accepts them
+package upload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+)
+
+const (
+	minBackoff = 5 * time.Second
+	maxBackoff = 10 * time.Minute
+	// Nothing to retry, wait for a push
+	idleWait = time.Hour
+)
+
+// Uploader sends one game to the server. Returning a RejectedError drops the game, any other error retries it later.
+type Uploader func(game pso.QuestRun) error
+
+// RejectedError is the server refusing a game in a way retrying won't fix, a 4xx
+type RejectedError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("server rejected game with status %v: %v", e.StatusCode, e.Message)
+}
+
+type Queue struct {
+	dir         string
+	lock        sync.Mutex
+	pending     []string
+	uploading   bool
+	lastError   string
+	backoff     time.Duration
+	nextAttempt time.Time
+	wake        chan struct{}
+}
+
+// Open loads the games left in dir from earlier runs of the client, oldest first
+func Open(dir string) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	pending := make([]string, len(files))
+	for i, file := range files {
+		pending[i] = filepath.Base(file)
+	}
+	sort.Strings(pending)
+	return &Queue{dir: dir, pending: pending, wake: make(chan struct{}, 1)}, nil
+}
+
+// Push writes game to disk before queueing it, so it survives the client exiting
+func (q *Queue) Push(game pso.QuestRun) error {
+	jsonBytes, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("Push: %w", err)
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	queuedAt := time.Now().UnixNano()
+	file := fmt.Sprintf("%020d.json", queuedAt)
+	for i := int64(1); q.isPending(file); i++ {
+		file = fmt.Sprintf("%020d.json", queuedAt+i)
+	}
+	temp := filepath.Join(q.dir, file+".tmp")
+	if err := os.WriteFile(temp, jsonBytes, 0644); err != nil {
+		return fmt.Errorf("Push: %w", err)
+	}
+	if err := os.Rename(temp, filepath.Join(q.dir, file)); err != nil {
+		return fmt.Errorf("Push: %w", err)
+	}
+	q.pending = append(q.pending, file)
+	q.signal()
+	return nil
+}
+
+func (q *Queue) isPending(file string) bool {
+	for _, pending := range q.pending {
+		if pending == file {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.pending)
+}
+
+// LastError is why the last upload failed, empty once one succeeds
+func (q *Queue) LastError() string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.lastError
+}
+
+// NextAttempt is when a failed upload will be retried, zero when nothing is waiting on a backoff
+func (q *Queue) NextAttempt() time.Time {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.nextAttempt
+}
+
+// RetryNow skips the current backoff
+func (q *Queue) RetryNow() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.nextAttempt = time.Time{}
+	q.signal()
+}
+
+// UploadNext tries the oldest game if its backoff has passed, returning false when nothing was tried
+func (q *Queue) UploadNext(upload Uploader) bool {
+	q.lock.Lock()
+	if len(q.pending) == 0 || q.uploading || time.Now().Before(q.nextAttempt) {
+		q.lock.Unlock()
+		return false
+	}
+	q.uploading = true
+	file := q.pending[0]
+	q.lock.Unlock()
+
+	game, err := q.read(file)
+	if err == nil {
+		err = upload(game)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.uploading = false
+	defer q.signal()
+	rejected := &RejectedError{}
+	switch {
+	case err == nil:
+		q.lastError = ""
+		q.backoff = 0
+		q.nextAttempt = time.Time{}
+		q.drop(file)
+	case errors.As(err, &rejected):
+		log.Printf("Dropping queued game %v: %v", file, err)
+		q.lastError = err.Error()
+		q.drop(file)
+	default:
+		q.backoff *= 2
+		if q.backoff < minBackoff {
+			q.backoff = minBackoff
+		} else if q.backoff > maxBackoff {
+			q.backoff = maxBackoff
+		}
+		q.nextAttempt = time.Now().Add(q.backoff)
+		q.lastError = err.Error()
+		log.Printf("Upload of %v failed, retrying in %v: %v", file, q.backoff, err)
+	}
+	return true
+}
+
+func (q *Queue) read(file string) (pso.QuestRun, error) {
+	game := pso.QuestRun{}
+	jsonBytes, err := os.ReadFile(filepath.Join(q.dir, file))
+	if err == nil {
+		err = json.Unmarshal(jsonBytes, &game)
+	}
+	if err != nil {
+		// Retrying a missing or corrupt file would block the queue forever
+		return game, &RejectedError{Message: fmt.Sprintf("unreadable queued game: %v", err)}
+	}
+	return game, nil
+}
+
+func (q *Queue) drop(file string) {
+	if err := os.Remove(filepath.Join(q.dir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Unable to remove queued game %v: %v", file, err)
+	}
+	for i, pending := range q.pending {
+		if pending == file {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+}
+
+// Run uploads queued games as they're pushed, backing off while the server can't be reached, until done is closed
+func (q *Queue) Run(upload Uploader, done <-chan struct{}) {
+	for {
+		for q.UploadNext(upload) {
+		}
+		select {
+		case <-done:
+			return
+		case <-q.wake:
+		case <-time.After(q.untilNextAttempt()):
+		}
+	}
+}
+
+func (q *Queue) untilNextAttempt() time.Duration {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.pending) == 0 || q.uploading {
+		return idleWait
+	}
+	return time.Until(q.nextAttempt)
+}
@@ -0,0 +1,153 @@
+package upload_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/client/internal/pso"
+	"github.com/phelix-/psostats/v2/client/internal/upload"
+)
+
+type fakeServer struct {
+	uploaded  []string
+	responses []error
+}
+
+func (s *fakeServer) upload(game pso.QuestRun) error {
+	var err error
+	if len(s.responses) > 0 {
+		err = s.responses[0]
+		s.responses = s.responses[1:]
+	}
+	if err == nil {
+		s.uploaded = append(s.uploaded, game.QuestName)
+	}
+	return err
+}
+
+func openQueue(dir string, t *testing.T) *upload.Queue {
+	queue, err := upload.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue
+}
+
+func push(queue *upload.Queue, questName string, t *testing.T) {
+	if err := queue.Push(pso.QuestRun{QuestName: questName}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueue_UploadsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	queue := openQueue(dir, t)
+	push(queue, "Mop-up Operation #1", t)
+	push(queue, "Mop-up Operation #2", t)
+	server := &fakeServer{}
+	for queue.UploadNext(server.upload) {
+	}
+	if len(server.uploaded) != 2 || server.uploaded[0] != "Mop-up Operation #1" || server.uploaded[1] != "Mop-up Operation #2" {
+		t.Errorf("Expected both games uploaded in order but got %v", server.uploaded)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); queue.Len() != 0 || len(files) != 0 {
+		t.Errorf("Expected an empty queue but got %v queued, files %v", queue.Len(), files)
+	}
+}
+
+func TestQueue_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	push(openQueue(dir, t), "Mop-up Operation #1", t)
+
+	reopened := openQueue(dir, t)
+	if reopened.Len() != 1 {
+		t.Fatalf("Expected 1 queued game after reopening but got %v", reopened.Len())
+	}
+	server := &fakeServer{}
+	reopened.UploadNext(server.upload)
+	if len(server.uploaded) != 1 || server.uploaded[0] != "Mop-up Operation #1" {
+		t.Errorf("Expected the queued game to upload but got %v", server.uploaded)
+	}
+}
+
+func TestQueue_RetriesWithBackoff(t *testing.T) {
+	queue := openQueue(t.TempDir(), t)
+	push(queue, "Mop-up Operation #1", t)
+	server := &fakeServer{responses: []error{errors.New("connection refused"), errors.New("got response status 503")}}
+
+	before := time.Now()
+	if !queue.UploadNext(server.upload) {
+		t.Fatal("Expected an upload attempt")
+	}
+	firstBackoff := queue.NextAttempt().Sub(before)
+	if queue.Len() != 1 || queue.LastError() != "connection refused" || firstBackoff < 4*time.Second {
+		t.Errorf("Expected the game kept with a backoff but got %v queued, '%v', %v", queue.Len(), queue.LastError(), firstBackoff)
+	}
+	if queue.UploadNext(server.upload) {
+		t.Error("Expected no attempt until the backoff passes")
+	}
+
+	queue.RetryNow()
+	before = time.Now()
+	queue.UploadNext(server.upload)
+	if secondBackoff := queue.NextAttempt().Sub(before); secondBackoff < 2*firstBackoff-time.Second {
+		t.Errorf("Expected the backoff to double from %v but got %v", firstBackoff, secondBackoff)
+	}
+
+	queue.RetryNow()
+	queue.UploadNext(server.upload)
+	if queue.Len() != 0 || queue.LastError() != "" || !queue.NextAttempt().IsZero() {
+		t.Errorf("Expected a successful upload to clear the queue and error but got %v '%v'", queue.Len(), queue.LastError())
+	}
+}
+
+func TestQueue_DropsRejectedGames(t *testing.T) {
+	queue := openQueue(t.TempDir(), t)
+	push(queue, "Mop-up Operation #1", t)
+	push(queue, "Mop-up Operation #2", t)
+	server := &fakeServer{responses: []error{&upload.RejectedError{StatusCode: 400, Message: "bad game"}}}
+	queue.UploadNext(server.upload)
+	if queue.Len() != 1 || !strings.Contains(queue.LastError(), "400") {
+		t.Errorf("Expected the rejected game dropped and reported but got %v queued, '%v'", queue.Len(), queue.LastError())
+	}
+	if !queue.UploadNext(server.upload) || len(server.uploaded) != 1 || server.uploaded[0] != "Mop-up Operation #2" {
+		t.Errorf("Expected the next game to upload right away but got %v", server.uploaded)
+	}
+}
+
+func TestQueue_DropsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000001.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	queue := openQueue(dir, t)
+	server := &fakeServer{}
+	queue.UploadNext(server.upload)
+	if queue.Len() != 0 || len(server.uploaded) != 0 || !strings.Contains(queue.LastError(), "unreadable") {
+		t.Errorf("Expected the corrupt file dropped but got %v queued, '%v'", queue.Len(), queue.LastError())
+	}
+}
+
+func TestQueue_RunUploadsPushedGames(t *testing.T) {
+	queue := openQueue(t.TempDir(), t)
+	uploaded := make(chan string, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go queue.Run(func(game pso.QuestRun) error {
+		uploaded <- game.QuestName
+		return nil
+	}, done)
+	push(queue, "Mop-up Operation #1", t)
+	select {
+	case questName := <-uploaded:
+		if questName != "Mop-up Operation #1" {
+			t.Errorf("Unexpected upload %v", questName)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected Run to upload the pushed game")
+	}
+}
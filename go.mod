@@ -10,6 +10,7 @@ require (
 	github.com/hishboy/gocommons v0.0.0-20160108023425-89887b2ade6d
 	github.com/valyala/fasthttp v1.18.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/sys v0.0.0-20201210223839-7e3030f88018
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -22,5 +23,4 @@ require (
 	github.com/nsf/termbox-go v0.0.0-20190121233118-02980233997d // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/tcplisten v0.0.0-20161114210144-ceec8f93295a // indirect
-	golang.org/x/sys v0.0.0-20201210223839-7e3030f88018 // indirect
 )
@@ -0,0 +1,152 @@
+package model
+
+// DataFrameDelta is a DataFrame stored as what changed since the frame before it, nil fields are unchanged.
+// The first delta in a run is against an empty frame so it carries everything.
+type DataFrameDelta struct {
+	// Milliseconds since the previous frame, unix milliseconds on the first one
+	Time          int64
+	HP            *uint16  `json:",omitempty"`
+	TP            *uint16  `json:",omitempty"`
+	PB            *float32 `json:",omitempty"`
+	MesetaCharged *int     `json:",omitempty"`
+	Map           *uint16  `json:",omitempty"`
+	MapVariation  *uint16  `json:",omitempty"`
+	ShiftaLvl     *int16   `json:",omitempty"`
+	DebandLvl     *int16   `json:",omitempty"`
+	Invincible    *bool    `json:",omitempty"`
+	FT            *uint16  `json:",omitempty"`
+	DT            *uint16  `json:",omitempty"`
+	CT            *uint16  `json:",omitempty"`
+	MonstersAlive *int     `json:",omitempty"`
+	DamageDealt   *int64   `json:",omitempty"`
+	Kills         *int     `json:",omitempty"`
+	State         *uint16  `json:",omitempty"`
+	Weapon        *string  `json:",omitempty"`
+	// Players and monsters that moved or changed, and the ones no longer in the frame
+	PlayerByGcLocation map[string]Location     `json:",omitempty"`
+	RemovedPlayers     []string                `json:",omitempty"`
+	MonsterLocation    map[int]MonsterLocation `json:",omitempty"`
+	RemovedMonsters    []int                   `json:",omitempty"`
+}
+
+// Millis is when the frame was taken in unix milliseconds, legacy frames only have second precision
+func (frame DataFrame) Millis() int64 {
+	if frame.TimeMillis != 0 {
+		return frame.TimeMillis
+	}
+	return frame.Time * 1000
+}
+
+// EncodeDataFrame stores current as a delta against previous, pass an empty frame for the first one
+func EncodeDataFrame(previous DataFrame, current DataFrame) DataFrameDelta {
+	delta := DataFrameDelta{
+		Time:          current.Millis() - previous.Millis(),
+		HP:            changed(previous.HP, current.HP),
+		TP:            changed(previous.TP, current.TP),
+		PB:            changed(previous.PB, current.PB),
+		MesetaCharged: changed(previous.MesetaCharged, current.MesetaCharged),
+		Map:           changed(previous.Map, current.Map),
+		MapVariation:  changed(previous.MapVariation, current.MapVariation),
+		ShiftaLvl:     changed(previous.ShiftaLvl, current.ShiftaLvl),
+		DebandLvl:     changed(previous.DebandLvl, current.DebandLvl),
+		Invincible:    changed(previous.Invincible, current.Invincible),
+		FT:            changed(previous.FT, current.FT),
+		DT:            changed(previous.DT, current.DT),
+		CT:            changed(previous.CT, current.CT),
+		MonstersAlive: changed(previous.MonstersAlive, current.MonstersAlive),
+		DamageDealt:   changed(previous.DamageDealt, current.DamageDealt),
+		Kills:         changed(previous.Kills, current.Kills),
+		State:         changed(previous.State, current.State),
+		Weapon:        changed(previous.Weapon, current.Weapon),
+	}
+	delta.PlayerByGcLocation, delta.RemovedPlayers = changedEntries(previous.PlayerByGcLocation, current.PlayerByGcLocation)
+	delta.MonsterLocation, delta.RemovedMonsters = changedEntries(previous.MonsterLocation, current.MonsterLocation)
+	return delta
+}
+
+// DecodeDataFrames rebuilds full frames from deltas
+func DecodeDataFrames(deltas []DataFrameDelta) []DataFrame {
+	frames := make([]DataFrame, 0, len(deltas))
+	previous := DataFrame{}
+	for _, delta := range deltas {
+		frame := previous
+		frame.TimeMillis = previous.Millis() + delta.Time
+		frame.Time = frame.TimeMillis / 1000
+		apply(delta.HP, &frame.HP)
+		apply(delta.TP, &frame.TP)
+		apply(delta.PB, &frame.PB)
+		apply(delta.MesetaCharged, &frame.MesetaCharged)
+		apply(delta.Map, &frame.Map)
+		apply(delta.MapVariation, &frame.MapVariation)
+		apply(delta.ShiftaLvl, &frame.ShiftaLvl)
+		apply(delta.DebandLvl, &frame.DebandLvl)
+		apply(delta.Invincible, &frame.Invincible)
+		apply(delta.FT, &frame.FT)
+		apply(delta.DT, &frame.DT)
+		apply(delta.CT, &frame.CT)
+		apply(delta.MonstersAlive, &frame.MonstersAlive)
+		apply(delta.DamageDealt, &frame.DamageDealt)
+		apply(delta.Kills, &frame.Kills)
+		apply(delta.State, &frame.State)
+		apply(delta.Weapon, &frame.Weapon)
+		frame.PlayerByGcLocation = applyEntries(previous.PlayerByGcLocation, delta.PlayerByGcLocation, delta.RemovedPlayers)
+		frame.MonsterLocation = applyEntries(previous.MonsterLocation, delta.MonsterLocation, delta.RemovedMonsters)
+		frames = append(frames, frame)
+		previous = frame
+	}
+	return frames
+}
+
+// GetDataFrames returns the run's frames in whichever encoding it was uploaded with
+func (questRun *QuestRun) GetDataFrames() []DataFrame {
+	if len(questRun.DataFrames) == 0 && len(questRun.DataFrameDeltas) > 0 {
+		return DecodeDataFrames(questRun.DataFrameDeltas)
+	}
+	return questRun.DataFrames
+}
+
+func changed[T comparable](previous T, current T) *T {
+	if previous == current {
+		return nil
+	}
+	return &current
+}
+
+func apply[T any](value *T, target *T) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+func changedEntries[K comparable, V comparable](previous map[K]V, current map[K]V) (map[K]V, []K) {
+	var changedEntries map[K]V
+	var removed []K
+	for key, value := range current {
+		if previousValue, found := previous[key]; !found || previousValue != value {
+			if changedEntries == nil {
+				changedEntries = make(map[K]V)
+			}
+			changedEntries[key] = value
+		}
+	}
+	for key := range previous {
+		if _, found := current[key]; !found {
+			removed = append(removed, key)
+		}
+	}
+	return changedEntries, removed
+}
+
+func applyEntries[K comparable, V any](previous map[K]V, updated map[K]V, removed []K) map[K]V {
+	entries := make(map[K]V, len(previous)+len(updated))
+	for key, value := range previous {
+		entries[key] = value
+	}
+	for key, value := range updated {
+		entries[key] = value
+	}
+	for _, key := range removed {
+		delete(entries, key)
+	}
+	return entries
+}
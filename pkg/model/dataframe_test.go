@@ -0,0 +1,85 @@
+package model_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+func testFrames() []model.DataFrame {
+	return []model.DataFrame{
+		{
+			Time: 1618000000, TimeMillis: 1618000000000, HP: 1500, TP: 200, Map: 1, Weapon: "00010000",
+			PlayerByGcLocation: map[string]model.Location{"42": {X: 1, Z: 2}, "43": {X: 5, Z: 5}},
+			MonsterLocation:    map[int]model.MonsterLocation{7: {X: 10, HP: 300}, 8: {X: 20, HP: 400}},
+		},
+		{
+			Time: 1618000000, TimeMillis: 1618000000100, HP: 1450, TP: 200, Map: 1, Weapon: "00010000",
+			PlayerByGcLocation: map[string]model.Location{"42": {X: 1.5, Z: 2}, "43": {X: 5, Z: 5}},
+			MonsterLocation:    map[int]model.MonsterLocation{7: {X: 10, HP: 250, Frozen: true}, 8: {X: 20, HP: 400}},
+		},
+		{
+			Time: 1618000001, TimeMillis: 1618000001000, HP: 0, TP: 180, Map: 2, Weapon: "00010000",
+			PlayerByGcLocation: map[string]model.Location{"42": {X: 1.5, Z: 2}},
+			MonsterLocation:    map[int]model.MonsterLocation{8: {X: 20, HP: 400}},
+		},
+	}
+}
+
+func encode(frames []model.DataFrame) []model.DataFrameDelta {
+	deltas := make([]model.DataFrameDelta, len(frames))
+	previous := model.DataFrame{}
+	for i, frame := range frames {
+		deltas[i] = model.EncodeDataFrame(previous, frame)
+		previous = frame
+	}
+	return deltas
+}
+
+func TestDataFrameDeltas_RoundTrip(t *testing.T) {
+	frames := testFrames()
+	jsonBytes, err := json.Marshal(encode(frames))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deltas := make([]model.DataFrameDelta, 0)
+	if err := json.Unmarshal(jsonBytes, &deltas); err != nil {
+		t.Fatal(err)
+	}
+	decoded := model.DecodeDataFrames(deltas)
+	if !reflect.DeepEqual(frames, decoded) {
+		t.Errorf("Expected %+v but got %+v", frames, decoded)
+	}
+}
+
+func TestEncodeDataFrame_OnlyCarriesChanges(t *testing.T) {
+	frames := testFrames()
+	delta := model.EncodeDataFrame(frames[0], frames[1])
+	if delta.Time != 100 || delta.HP == nil || *delta.HP != 1450 || delta.TP != nil || delta.Map != nil || delta.Weapon != nil {
+		t.Errorf("Expected only the time and hp to change but got %+v", delta)
+	}
+	if len(delta.PlayerByGcLocation) != 1 || len(delta.MonsterLocation) != 1 || !delta.MonsterLocation[7].Frozen {
+		t.Errorf("Expected one player and one monster to change but got %v %v", delta.PlayerByGcLocation, delta.MonsterLocation)
+	}
+
+	delta = model.EncodeDataFrame(frames[1], frames[2])
+	if delta.HP == nil || *delta.HP != 0 {
+		t.Errorf("Expected hp dropping to 0 to be kept but got %v", delta.HP)
+	}
+	if !reflect.DeepEqual(delta.RemovedPlayers, []string{"43"}) || !reflect.DeepEqual(delta.RemovedMonsters, []int{7}) {
+		t.Errorf("Expected player 43 and monster 7 removed but got %v %v", delta.RemovedPlayers, delta.RemovedMonsters)
+	}
+}
+
+func TestQuestRun_GetDataFrames(t *testing.T) {
+	legacy := model.QuestRun{DataFrames: []model.DataFrame{{Time: 1618000000, HP: 1500}}}
+	if frames := legacy.GetDataFrames(); len(frames) != 1 || frames[0].Millis() != 1618000000000 {
+		t.Errorf("Expected the legacy frame but got %+v", frames)
+	}
+	encoded := model.QuestRun{DataFrameDeltas: encode(testFrames())}
+	if frames := encoded.GetDataFrames(); len(frames) != 3 || frames[1].HP != 1450 {
+		t.Errorf("Expected decoded frames but got %+v", frames)
+	}
+}
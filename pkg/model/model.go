@@ -19,6 +19,8 @@ type BasePlayerInfo struct {
 	SectionId uint8
 	Level     uint16
 	Class     string
+	// Party slot, AllPlayers skips empty slots so monster damage and kills are keyed by this instead of the index
+	Slot int
 }
 
 type QuestRun struct {
@@ -82,14 +84,73 @@ type QuestRun struct {
 	TelepipeUsed        uint8
 	TimeByState         map[int]uint64
 	TechsCast           map[string]int
+	TechCasts           []TechCast
+	ItemPickups         []ItemPickup
+	PhotonBlasts        []PhotonBlast
+	StatusEffects       StatusEffects
+	TrapUses            []TrapUse
 	Points              uint16
 	DataFrames          []DataFrame
+	DataFrameRate       int
+	DataFrameDeltas     []DataFrameDelta
+	Attempts            int
+	Resets              int
+}
+
+// TechCast is one technique cast by the player the run was recorded by
+type TechCast struct {
+	Second int
+	Time   time.Time
+	Tech   string
+	Level  uint16
+	// TP before the cast and how far it dropped while casting
+	TpStart uint16
+	TpSpent uint16
+	// Display name of the weapon equipped when the cast started
+	Weapon string
+}
+
+// AddTp counts a drop to tp since the cast started as TP spent on it
+func (cast *TechCast) AddTp(tp uint16) {
+	if tp < cast.TpStart && cast.TpStart-tp > cast.TpSpent {
+		cast.TpSpent = cast.TpStart - tp
+	}
+}
+
+// ItemPickup is an item that showed up in the player's inventory during the quest
+type ItemPickup struct {
+	Second int
+	Time   time.Time
+	// Item type, group and index in hex
+	Code string
+	Name string
+	// Name with grind, special and stats
+	Display string
+	Grind   uint8
+	Special string
+	// How many were picked up, for tools
+	Count uint8
+	Rare  bool
+}
+
+// PhotonBlast is a blast fired by the player the run was recorded by
+type PhotonBlast struct {
+	Second int
+	Time   time.Time
+	// The blast fired, only known when the mag has one. Which blast was picked from the action palette isn't read.
+	Blast string
+	// Every blast on the mag, one of them was fired
+	MagBlasts []string `json:",omitempty"`
+	Mag       string
+	Sync      uint8
+	IQ        uint8
 }
 
 type QuestRunSplit struct {
 	Name        string
 	Index       int
 	StartSecond int
+	EndSecond   int
 	Start       time.Time
 	End         time.Time
 }
@@ -103,6 +164,50 @@ type Monster struct {
 	Alive      bool
 	Frame1     bool
 	Location   Location
+	// Hp the monster spawned with
+	MaxHp uint16
+	// Damage taken keyed by the party slot of the player, see BasePlayerInfo.Slot. nil for games uploaded before it was tracked
+	DamageByPlayer map[int]int64
+	// Party slot of the player with the last hit
+	KilledBy   int
+	TimeToKill time.Duration
+	// Damage past 0 hp on the killing blow, only known when the pso server lets hp go negative
+	Overkill uint16
+	// How long each status was on the monster, and how many times it was applied
+	FrozenTime    time.Duration
+	ConfusedTime  time.Duration
+	ParalyzedTime time.Duration
+	Freezes       int
+	Confusions    int
+	Paralyses     int
+}
+
+// StatusEffects totals the status effects on monsters over a quest, and how many monsters the player's traps caught
+type StatusEffects struct {
+	FrozenTime       time.Duration
+	ConfusedTime     time.Duration
+	ParalyzedTime    time.Duration
+	Freezes          int
+	Confusions       int
+	Paralyses        int
+	FreezeTrapsUsed  int
+	ConfuseTrapsUsed int
+	// Monsters frozen or confused soon after one of the player's traps was set
+	FrozenByTraps   int
+	ConfusedByTraps int
+}
+
+const (
+	TrapFreeze  = "Freeze"
+	TrapConfuse = "Confuse"
+)
+
+// TrapUse is a freeze or confuse trap set by the player the run was recorded by
+type TrapUse struct {
+	Second           int
+	Time             time.Time
+	Trap             string
+	MonstersAffected int
 }
 
 type MonsterLocation struct {
@@ -150,6 +255,8 @@ type DataFrame struct {
 	PlayerByGcLocation map[string]Location
 	PlayerLocation     map[int]Location
 	MonsterLocation    map[int]MonsterLocation
+	// Unix milliseconds, only set on frames decoded from DataFrameDeltas
+	TimeMillis int64 `json:",omitempty"`
 }
 
 type BossData struct {
@@ -237,6 +344,19 @@ type PostGameResponse struct {
 	Id     string
 }
 
+// CreateApiTokenRequest exchanges a user's password for an api token, Name is shown when listing tokens
+type CreateApiTokenRequest struct {
+	Name string
+}
+
+// ApiToken authenticates a client in place of its password. Token is the secret, only sent when it's created.
+type ApiToken struct {
+	Id      string
+	Name    string
+	Created time.Time
+	Token   string `json:",omitempty"`
+}
+
 type Equipment struct {
 	Id              string
 	UnitxtIndex     string
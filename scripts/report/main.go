@@ -6,7 +6,7 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
-	"github.com/phelix-/psostats/v2/pkg/common"
+	"github.com/phelix-/psostats/v2/pkg/psoclasses"
 	"github.com/phelix-/psostats/v2/pkg/model"
 	"io"
 	"log"
@@ -125,8 +125,8 @@ func main() {
 		}
 	}
 
-	classesByName := make(map[string]common.PsoClass)
-	for _, class := range common.GetAllClasses() {
+	classesByName := make(map[string]psoclasses.PsoClass)
+	for _, class := range psoclasses.GetAll() {
 		classesByName[class.Name] = class
 	}
 	for player,games := range playerGames {
@@ -69,6 +69,7 @@ func WriteGameById(questRun *model.QuestRun, dynamoClient *dynamodb.DynamoDB) (s
 	questRun.Id = fmt.Sprintf("%d", gameId)
 	_ = writeDataFrames(questRun, dynamoClient)
 	questRun.DataFrames = make([]model.DataFrame, 0)
+	questRun.DataFrameDeltas = nil
 	gameGzip, err := Compress(questRun)
 	if err != nil {
 		return "", err
@@ -135,29 +136,28 @@ func getPlayerIndex(questRun model.QuestRun) (int, error) {
 }
 
 type QuestDataFrameItem struct {
-	QuestAndPlayerId     string
-	CompressedDataFrames []byte
+	QuestAndPlayerId          string
+	CompressedDataFrames      []byte
+	CompressedDataFrameDeltas []byte
 }
 
 func writeDataFrames(questRun *model.QuestRun, db *dynamodb.DynamoDB) error {
-	buffer := new(bytes.Buffer)
-	writer := gzip.NewWriter(buffer)
-	jsonQuestBytes, err := json.Marshal(questRun.DataFrames)
-	if err != nil {
-		return err
-	}
-	_, err = writer.Write(jsonQuestBytes)
-	if err != nil {
-		return err
-	}
-	err = writer.Flush()
-	if err != nil {
-		return err
-	}
 	index, _ := getPlayerIndex(*questRun)
 	item := QuestDataFrameItem{
-		QuestAndPlayerId:     fmt.Sprintf("%s_%d", questRun.Id, index),
-		CompressedDataFrames: buffer.Bytes(),
+		QuestAndPlayerId: fmt.Sprintf("%s_%d", questRun.Id, index),
+	}
+	if len(questRun.DataFrameDeltas) > 0 {
+		compressed, err := compressJson(questRun.DataFrameDeltas)
+		if err != nil {
+			return err
+		}
+		item.CompressedDataFrameDeltas = compressed
+	} else {
+		compressed, err := compressJson(questRun.DataFrames)
+		if err != nil {
+			return err
+		}
+		item.CompressedDataFrames = compressed
 	}
 	marshalled, err := dynamodbattribute.MarshalMap(item)
 	if err != nil {
@@ -192,23 +192,47 @@ func GetDataFrames(gameId string, gem int, db *dynamodb.DynamoDB) ([]model.DataF
 		return nil, err
 	}
 
-	buffer := bytes.NewBuffer(questDataFrameItem.CompressedDataFrames)
-	reader, err := gzip.NewReader(buffer)
+	if len(questDataFrameItem.CompressedDataFrameDeltas) > 0 {
+		deltas := make([]model.DataFrameDelta, 0)
+		err = decompressJson(questDataFrameItem.CompressedDataFrameDeltas, &deltas)
+		return model.DecodeDataFrames(deltas), err
+	}
+	dataFrames := make([]model.DataFrame, 0)
+	err = decompressJson(questDataFrameItem.CompressedDataFrames, &dataFrames)
+	return dataFrames, err
+}
+
+func compressJson(value interface{}) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	writer := gzip.NewWriter(buffer)
+	jsonBytes, err := json.Marshal(value)
 	if err != nil {
 		return nil, err
 	}
+	_, err = writer.Write(jsonBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Flush()
+	return buffer.Bytes(), err
+}
+
+func decompressJson(compressed []byte, value interface{}) error {
+	reader, err := gzip.NewReader(bytes.NewBuffer(compressed))
+	if err != nil {
+		return err
+	}
 	jsonBytes, err := io.ReadAll(reader)
 	if err != io.ErrUnexpectedEOF {
-		return nil, err
+		return err
 	}
-	dataFrames := make([]model.DataFrame, 0)
-	err = json.Unmarshal(jsonBytes, &dataFrames)
-	return dataFrames, err
+	return json.Unmarshal(jsonBytes, value)
 }
 
 func AttachGameToId(questRun model.QuestRun, id string, dynamoClient *dynamodb.DynamoDB) error {
 	_ = writeDataFrames(&questRun, dynamoClient)
 	questRun.DataFrames = make([]model.DataFrame, 0)
+	questRun.DataFrameDeltas = nil
 	gameGzip, err := Compress(&questRun)
 	if err != nil {
 		return err
@@ -1031,6 +1055,9 @@ func GetGame(gameId string, gem int, dynamoClient *dynamodb.DynamoDB) (*model.Qu
 		return nil, err
 	}
 
+	if len(questRun.DataFrames) == 0 {
+		questRun.DataFrames = questRun.GetDataFrames()
+	}
 	if len(questRun.DataFrames) == 0 {
 		playerIndex, _ := getPlayerIndex(questRun)
 		dataFrames, err := GetDataFrames(questRun.Id, playerIndex, dynamoClient)
@@ -1,3 +1,6 @@
+//go:build dynamodb
+
+// Needs the dynamodb-local from db_tests/docker-compose.yml, run with -tags dynamodb
 package db_test
 
 import (
@@ -35,7 +38,7 @@ func TestGetPlayerRecentGames(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	returned, err := db.GetGame(id, dynamoClient)
+	returned, err := db.GetGame(id, -1, dynamoClient)
 	if err != nil {
 		t.Error(err)
 	}
@@ -66,7 +69,6 @@ func createMockGame() *model.QuestRun {
 		DeathCount:          2,
 		HP:                  nil,
 		TP:                  nil,
-		Meseta:              nil,
 		MesetaCharged:       nil,
 		Room:                nil,
 		IllegalShifta:       false,
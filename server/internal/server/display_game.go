@@ -9,6 +9,7 @@ import (
 	"log"
 	"sort"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
 )
@@ -36,22 +37,22 @@ func (s *Server) GamePageV3(c *fiber.Ctx) error {
 	playerDataFrames := make(map[int][]model.DataFrame)
 	if fullGame.P1Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 1, s.dynamoClient); err == nil {
-			playerDataFrames[0] = dataFrames
+			playerDataFrames[0] = framesBySecond(dataFrames)
 		}
 	}
 	if fullGame.P2Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 2, s.dynamoClient); err == nil {
-			playerDataFrames[1] = dataFrames
+			playerDataFrames[1] = framesBySecond(dataFrames)
 		}
 	}
 	if fullGame.P3Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 3, s.dynamoClient); err == nil {
-			playerDataFrames[2] = dataFrames
+			playerDataFrames[2] = framesBySecond(dataFrames)
 		}
 	}
 	if fullGame.P4Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 4, s.dynamoClient); err == nil {
-			playerDataFrames[3] = dataFrames
+			playerDataFrames[3] = framesBySecond(dataFrames)
 		}
 	}
 
@@ -62,6 +63,7 @@ func (s *Server) GamePageV3(c *fiber.Ctx) error {
 		if err != nil {
 			return err
 		}
+		game.DataFrames = framesBySecond(game.DataFrames)
 
 		invincibleRanges := make(map[int]int)
 		invincibleStart := -1
@@ -263,22 +265,22 @@ func (s *Server) GamePageV4(c *fiber.Ctx) error {
 	playerDataFrames := make(map[int][]model.DataFrame)
 	if fullGame.P1Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 1, s.dynamoClient); err == nil {
-			playerDataFrames[0] = dataFrames
+			playerDataFrames[0] = framesBySecond(dataFrames)
 		}
 	}
 	if fullGame.P2Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 2, s.dynamoClient); err == nil {
-			playerDataFrames[1] = dataFrames
+			playerDataFrames[1] = framesBySecond(dataFrames)
 		}
 	}
 	if fullGame.P3Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 3, s.dynamoClient); err == nil {
-			playerDataFrames[2] = dataFrames
+			playerDataFrames[2] = framesBySecond(dataFrames)
 		}
 	}
 	if fullGame.P4Gzip != nil {
 		if dataFrames, err := db.GetDataFrames(gameId, 4, s.dynamoClient); err == nil {
-			playerDataFrames[3] = dataFrames
+			playerDataFrames[3] = framesBySecond(dataFrames)
 		}
 	}
 
@@ -311,6 +313,8 @@ func (s *Server) GamePageV4(c *fiber.Ctx) error {
 			return err
 		}
 		dataFrames := string(jsonBytes)
+		// the map plays back every frame, everything else is charted a second at a time
+		game.DataFrames = framesBySecond(game.DataFrames)
 
 		invincibleRanges := make(map[int]int)
 		invincibleStart := -1
@@ -361,11 +365,11 @@ func (s *Server) GamePageV4(c *fiber.Ctx) error {
 			if frame.TP > maxTp {
 				maxTp = frame.TP
 			}
+			hasFacing = hasFacing || frame.PlayerByGcLocation[game.GuildCard].Facing > 0
 			nameForState := getNameForState(frame.State)
 			currentValue := timeByStateMap[nameForState.Display]
 			nameForState.Time = 1 + currentValue.Time
 			timeByStateMap[nameForState.Display] = nameForState
-			hasFacing = hasFacing || frame.PlayerByGcLocation[game.GuildCard].Facing > 0
 		}
 		for _, state := range timeByStateMap {
 			if state.Time > mostTime {
@@ -410,34 +414,44 @@ func (s *Server) GamePageV4(c *fiber.Ctx) error {
 		}
 		waves := getWaves(game)
 		wavesJson, _ := json.Marshal(waves)
+		killSpeeds := getKillSpeeds(game)
+		techUsage := getTechUsage(game, duration)
+		itemPickups, rareCount := getItemPickups(game)
 
 		model := struct {
-			Game               model.QuestRun
-			SectionId          string
-			HasPov             map[int]bool
-			FormattedQuestTime string
-			InvincibleRanges   map[int]int
-			Weapons            []model.Equipment
-			Barriers           []model.Equipment
-			Frames             []model.Equipment
-			Units              []model.Equipment
-			Mags               []model.Equipment
-			MapData            []MapData
-			PlayerIndex        int
-			TechsInOrder       [][]string
-			MostActions        int
-			TimeByState        []TimeAndStateDisplay
-			PlayerDataFrames   map[int][]model.DataFrame
-			SortedWeapons      []WeaponDisplay
-			MaxHp              uint16
-			MaxTp              uint16
-			HasFacing          bool
-			JsonMeshes         string
-			Monsters           string
-			DataFrames         string
-			MeshesByFloor      string
-			Waves              []Wave
-			WavesJson          string
+			Game                 model.QuestRun
+			SectionId            string
+			HasPov               map[int]bool
+			FormattedQuestTime   string
+			InvincibleRanges     map[int]int
+			Weapons              []model.Equipment
+			Barriers             []model.Equipment
+			Frames               []model.Equipment
+			Units                []model.Equipment
+			Mags                 []model.Equipment
+			MapData              []MapData
+			PlayerIndex          int
+			TechsInOrder         [][]string
+			MostActions          int
+			TimeByState          []TimeAndStateDisplay
+			PlayerDataFrames     map[int][]model.DataFrame
+			SortedWeapons        []WeaponDisplay
+			MaxHp                uint16
+			MaxTp                uint16
+			HasFacing            bool
+			JsonMeshes           string
+			Monsters             string
+			DataFrames           string
+			MeshesByFloor        string
+			Waves                []Wave
+			WavesJson            string
+			KillSpeeds           []EnemyKillSpeed
+			TechUsage            []TechUsage
+			TechCastsBySecond    map[int]*model.TechCast
+			ItemPickups          []ItemPickupDisplay
+			RareCount            int
+			TimelineEvents       []model.Event
+			PhotonBlastsBySecond map[int]*model.PhotonBlast
 		}{
 			Game:      *game,
 			SectionId: getSectionIdForQuest(game),
@@ -464,19 +478,26 @@ func (s *Server) GamePageV4(c *fiber.Ctx) error {
 				{"Resta", "Anti", "Reverser"},
 				{"Shifta", "Deband", "Ryuker"},
 				{"Jellen", "Zalure"}},
-			MostActions:      totalActions,
-			PlayerDataFrames: playerDataFrames,
-			TimeByState:      timeByState,
-			SortedWeapons:    weaponDisplay,
-			MaxHp:            maxHp,
-			MaxTp:            maxTp,
-			HasFacing:        hasFacing,
-			JsonMeshes:       jsonMeshes,
-			Monsters:         string(monsters),
-			DataFrames:       dataFrames,
-			MeshesByFloor:    jsonFloorMeshes,
-			Waves:            waves,
-			WavesJson:        string(wavesJson),
+			MostActions:          totalActions,
+			PlayerDataFrames:     playerDataFrames,
+			TimeByState:          timeByState,
+			SortedWeapons:        weaponDisplay,
+			MaxHp:                maxHp,
+			MaxTp:                maxTp,
+			HasFacing:            hasFacing,
+			JsonMeshes:           jsonMeshes,
+			Monsters:             string(monsters),
+			DataFrames:           dataFrames,
+			MeshesByFloor:        jsonFloorMeshes,
+			Waves:                waves,
+			WavesJson:            string(wavesJson),
+			KillSpeeds:           killSpeeds,
+			TechUsage:            techUsage,
+			TechCastsBySecond:    getTechCastsBySecond(game),
+			ItemPickups:          itemPickups,
+			RareCount:            rareCount,
+			TimelineEvents:       getTimelineEvents(game),
+			PhotonBlastsBySecond: getPhotonBlastsBySecond(game),
 		}
 		funcMap := template.FuncMap{
 			"add": func(a, b int) int { return a + b },
@@ -489,12 +510,17 @@ func (s *Server) GamePageV4(c *fiber.Ctx) error {
 }
 
 type WaveMonster struct {
-	Name       string
-	Id         uint16
-	UnitxtId   uint32
-	SpawnTime  time.Time
-	KilledTime time.Time
-	TimeAlive  string
+	Name           string
+	Id             uint16
+	UnitxtId       uint32
+	SpawnTime      time.Time
+	KilledTime     time.Time
+	TimeAlive      string
+	KilledBy       string
+	DamageByPlayer map[int]int64
+	FrozenTime     time.Duration
+	ConfusedTime   time.Duration
+	ParalyzedTime  time.Duration
 }
 
 type Wave struct {
@@ -504,6 +530,241 @@ type Wave struct {
 	FirstSpawn        time.Time
 	Duration          time.Duration
 	FormattedDuration string
+	// Damage dealt to the wave by each player, most first. Empty for games uploaded before damage was tracked.
+	Damage []PlayerDamage
+	Carry  string
+	// Total time the wave's monsters spent with each status, empty when none had it
+	Frozen    string
+	Confused  string
+	Paralyzed string
+}
+
+type PlayerDamage struct {
+	Name    string
+	Damage  int64
+	Percent int
+}
+
+type EnemyKillSpeed struct {
+	Name       string
+	Kills      int
+	Average    string
+	Fastest    string
+	Slowest    string
+	averageTtk time.Duration
+}
+
+type TechUsage struct {
+	Tech           string
+	Casts          int
+	MaxLevel       uint16
+	TpSpent        int
+	CastsPerMinute string
+}
+
+// getTechUsage totals the TP spent on each tech and how often it was cast, most TP first
+func getTechUsage(game *model.QuestRun, duration time.Duration) []TechUsage {
+	usageByTech := make(map[string]*TechUsage)
+	for _, cast := range game.TechCasts {
+		usage, found := usageByTech[cast.Tech]
+		if !found {
+			usage = &TechUsage{Tech: cast.Tech}
+			usageByTech[cast.Tech] = usage
+		}
+		usage.Casts++
+		usage.TpSpent += int(cast.TpSpent)
+		if cast.Level > usage.MaxLevel {
+			usage.MaxLevel = cast.Level
+		}
+	}
+	techUsage := make([]TechUsage, 0, len(usageByTech))
+	for _, usage := range usageByTech {
+		if duration > 0 {
+			usage.CastsPerMinute = fmt.Sprintf("%.1f", float64(usage.Casts)/duration.Minutes())
+		}
+		techUsage = append(techUsage, *usage)
+	}
+	sort.Slice(techUsage, func(i, j int) bool {
+		if techUsage[i].TpSpent == techUsage[j].TpSpent {
+			return techUsage[i].Tech < techUsage[j].Tech
+		}
+		return techUsage[i].TpSpent > techUsage[j].TpSpent
+	})
+	return techUsage
+}
+
+// getTechCastsBySecond is the first tech cast in each second, to mark on the timeline
+func getTechCastsBySecond(game *model.QuestRun) map[int]*model.TechCast {
+	castsBySecond := make(map[int]*model.TechCast)
+	for i, cast := range game.TechCasts {
+		if _, found := castsBySecond[cast.Second]; !found {
+			castsBySecond[cast.Second] = &game.TechCasts[i]
+		}
+	}
+	return castsBySecond
+}
+
+type ItemPickupDisplay struct {
+	QuestTime string
+	Display   string
+	Rare      bool
+}
+
+// getItemPickups lists the items picked up in the order they were found, along with how many were rares
+func getItemPickups(game *model.QuestRun) ([]ItemPickupDisplay, int) {
+	pickups := make([]ItemPickupDisplay, len(game.ItemPickups))
+	rareCount := 0
+	for i, pickup := range game.ItemPickups {
+		pickups[i] = ItemPickupDisplay{
+			QuestTime: formatDurationSeconds(time.Duration(pickup.Second) * time.Second),
+			Display:   pickup.Display,
+			Rare:      pickup.Rare,
+		}
+		if pickup.Rare {
+			rareCount++
+		}
+	}
+	return pickups, rareCount
+}
+
+// getTimelineEvents leaves out photon blasts, they're marked on the PB line instead
+func getTimelineEvents(game *model.QuestRun) []model.Event {
+	events := make([]model.Event, 0, len(game.Events))
+	for _, event := range game.Events {
+		if !strings.HasPrefix(event.Description, "Photon Blast") {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// getPhotonBlastsBySecond is the blast fired in each second, to mark on the timeline
+func getPhotonBlastsBySecond(game *model.QuestRun) map[int]*model.PhotonBlast {
+	blastsBySecond := make(map[int]*model.PhotonBlast)
+	for i, photonBlast := range game.PhotonBlasts {
+		blastsBySecond[photonBlast.Second] = &game.PhotonBlasts[i]
+	}
+	return blastsBySecond
+}
+
+// framesBySecond keeps the first frame in each second of the quest, seconds without a frame repeat the one before.
+// The timeline charts a point a second and places its markers by second, runs recorded with a dataFrameRate
+// have several frames a second.
+func framesBySecond(frames []model.DataFrame) []model.DataFrame {
+	if len(frames) == 0 {
+		return frames
+	}
+	start := frames[0].Millis()
+	bySecond := make([]model.DataFrame, 0, len(frames))
+	for _, frame := range frames {
+		second := int((frame.Millis() - start) / 1000)
+		for len(bySecond) < second {
+			bySecond = append(bySecond, bySecond[len(bySecond)-1])
+		}
+		if len(bySecond) == second {
+			bySecond = append(bySecond, frame)
+		}
+	}
+	return bySecond
+}
+
+// playerName is the name of the player in party slot
+func playerName(game *model.QuestRun, slot int) string {
+	for _, player := range game.AllPlayers {
+		if player.Slot == slot {
+			return player.Name
+		}
+	}
+	return fmt.Sprintf("Player %d", slot+1)
+}
+
+// timeToKill falls back to the spawn and kill times for games uploaded before it was recorded
+func timeToKill(monster model.Monster) time.Duration {
+	if monster.TimeToKill > 0 {
+		return monster.TimeToKill
+	}
+	return monster.KilledTime.Sub(monster.SpawnTime)
+}
+
+// addWaveDamage totals the damage each player did to the wave's monsters and picks who carried it
+func addWaveDamage(game *model.QuestRun, wave *Wave) {
+	damageByPlayer := make(map[int]int64)
+	total := int64(0)
+	for _, monster := range wave.Monsters {
+		for player, damage := range monster.DamageByPlayer {
+			damageByPlayer[player] += damage
+			total += damage
+		}
+	}
+	wave.Damage = make([]PlayerDamage, 0, len(damageByPlayer))
+	if total == 0 {
+		return
+	}
+	for player, damage := range damageByPlayer {
+		wave.Damage = append(wave.Damage, PlayerDamage{
+			Name:    playerName(game, player),
+			Damage:  damage,
+			Percent: int(damage * 100 / total),
+		})
+	}
+	sort.Slice(wave.Damage, func(i, j int) bool {
+		return wave.Damage[i].Damage > wave.Damage[j].Damage
+	})
+	wave.Carry = wave.Damage[0].Name
+}
+
+// addWaveStatusEffects totals how long the wave's monsters were frozen, confused and paralyzed
+func addWaveStatusEffects(wave *Wave) {
+	frozen, confused, paralyzed := time.Duration(0), time.Duration(0), time.Duration(0)
+	for _, monster := range wave.Monsters {
+		frozen += monster.FrozenTime
+		confused += monster.ConfusedTime
+		paralyzed += monster.ParalyzedTime
+	}
+	if frozen > 0 {
+		wave.Frozen = formatDurationSecMilli(frozen)
+	}
+	if confused > 0 {
+		wave.Confused = formatDurationSecMilli(confused)
+	}
+	if paralyzed > 0 {
+		wave.Paralyzed = formatDurationSecMilli(paralyzed)
+	}
+}
+
+// getKillSpeeds is how long each type of enemy took to kill, slowest first
+func getKillSpeeds(game *model.QuestRun) []EnemyKillSpeed {
+	killTimes := make(map[string][]time.Duration)
+	for _, monster := range game.Monsters {
+		if monster.UnitxtId == 3 || monster.Alive || monster.KilledTime.Before(monster.SpawnTime) {
+			continue
+		}
+		killTimes[monster.Name] = append(killTimes[monster.Name], timeToKill(monster))
+	}
+	killSpeeds := make([]EnemyKillSpeed, 0, len(killTimes))
+	for name, times := range killTimes {
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		total := time.Duration(0)
+		for _, ttk := range times {
+			total += ttk
+		}
+		average := total / time.Duration(len(times))
+		killSpeeds = append(killSpeeds, EnemyKillSpeed{
+			Name:       name,
+			Kills:      len(times),
+			Average:    formatDurationSecMilli(average),
+			Fastest:    formatDurationSecMilli(times[0]),
+			Slowest:    formatDurationSecMilli(times[len(times)-1]),
+			averageTtk: average,
+		})
+	}
+	sort.Slice(killSpeeds, func(i, j int) bool {
+		if killSpeeds[i].averageTtk == killSpeeds[j].averageTtk {
+			return killSpeeds[i].Name < killSpeeds[j].Name
+		}
+		return killSpeeds[i].averageTtk > killSpeeds[j].averageTtk
+	})
+	return killSpeeds
 }
 
 func getWaves(game *model.QuestRun) []Wave {
@@ -524,6 +785,8 @@ func getWaves(game *model.QuestRun) []Wave {
 			if !wave.FirstSpawn.Before(time.UnixMilli(0)) {
 				wave.Duration = monster.SpawnTime.Sub(wave.FirstSpawn)
 				wave.FormattedDuration = formatDurationSecMilli(wave.Duration)
+				addWaveDamage(game, &wave)
+				addWaveStatusEffects(&wave)
 				waves = append(waves, wave)
 				wave = Wave{}
 			}
@@ -535,17 +798,28 @@ func getWaves(game *model.QuestRun) []Wave {
 		if monster.KilledTime.Before(monster.SpawnTime) {
 			timeAlive = "0"
 		}
+		killedBy := ""
+		if !monster.Alive && monster.DamageByPlayer != nil {
+			killedBy = playerName(game, monster.KilledBy)
+		}
 		wave.Monsters = append(wave.Monsters, WaveMonster{
-			Name:       monster.Name,
-			Id:         monster.Id,
-			UnitxtId:   monster.UnitxtId,
-			SpawnTime:  monster.SpawnTime,
-			KilledTime: monster.KilledTime,
-			TimeAlive:  timeAlive,
+			Name:           monster.Name,
+			Id:             monster.Id,
+			UnitxtId:       monster.UnitxtId,
+			SpawnTime:      monster.SpawnTime,
+			KilledTime:     monster.KilledTime,
+			TimeAlive:      timeAlive,
+			KilledBy:       killedBy,
+			DamageByPlayer: monster.DamageByPlayer,
+			FrozenTime:     monster.FrozenTime,
+			ConfusedTime:   monster.ConfusedTime,
+			ParalyzedTime:  monster.ParalyzedTime,
 		})
 	}
 	wave.Duration = game.QuestEndTime.Sub(wave.FirstSpawn)
 	wave.FormattedDuration = formatDurationSecMilli(wave.Duration)
+	addWaveDamage(game, &wave)
+	addWaveStatusEffects(&wave)
 	waves = append(waves, wave)
 	return waves
 }
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phelix-/psostats/v2/pkg/model"
+)
+
+func TestGetWaves_Carry(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	monster := func(id uint16, name string, spawn int, killed int, killedBy int, damage map[int]int64) model.Monster {
+		return model.Monster{
+			Name:           name,
+			Id:             id,
+			UnitxtId:       1,
+			SpawnTime:      start.Add(time.Duration(spawn) * time.Second),
+			KilledTime:     start.Add(time.Duration(killed) * time.Second),
+			KilledBy:       killedBy,
+			DamageByPlayer: damage,
+		}
+	}
+	game := &model.QuestRun{
+		// slot 1 is empty, damage and kills are keyed by slot rather than index in AllPlayers
+		AllPlayers:     []model.BasePlayerInfo{{Name: "phelix", Slot: 0}, {Name: "ender", Slot: 2}},
+		QuestStartTime: start,
+		QuestEndTime:   start.Add(30 * time.Second),
+		Monsters: map[int]model.Monster{
+			1: monster(1, "Booma", 1, 3, 0, map[int]int64{0: 300, 2: 100}),
+			2: monster(2, "Booma", 1, 5, 0, map[int]int64{0: 200}),
+			3: monster(3, "Gigobooma", 10, 20, 2, map[int]int64{0: 100, 2: 900}),
+		},
+	}
+
+	waves := getWaves(game)
+	if len(waves) != 2 {
+		t.Fatalf("Expected 2 waves but got %v", len(waves))
+	}
+	if waves[0].Carry != "phelix" || waves[0].Damage[0].Percent != 83 {
+		t.Errorf("Expected phelix to carry the first wave with 83%% but got %v", waves[0].Damage)
+	}
+	if waves[1].Carry != "ender" || waves[1].Monsters[0].KilledBy != "ender" {
+		t.Errorf("Expected ender to carry and kill in the second wave but got %v", waves[1])
+	}
+
+	killSpeeds := getKillSpeeds(game)
+	if len(killSpeeds) != 2 || killSpeeds[0].Name != "Gigobooma" {
+		t.Fatalf("Expected the slowest enemy type first but got %v", killSpeeds)
+	}
+	booma := killSpeeds[1]
+	if booma.Kills != 2 || booma.Average != formatDurationSecMilli(3*time.Second) ||
+		booma.Fastest != formatDurationSecMilli(2*time.Second) || booma.Slowest != formatDurationSecMilli(4*time.Second) {
+		t.Errorf("Unexpected booma kill speed %v", booma)
+	}
+}
+
+func TestGetTechUsage(t *testing.T) {
+	game := &model.QuestRun{
+		TechCasts: []model.TechCast{
+			{Second: 3, Tech: "Shifta", Level: 15, TpSpent: 20},
+			{Second: 4, Tech: "Deband", Level: 15, TpSpent: 20},
+			{Second: 4, Tech: "Razonde", Level: 30, TpSpent: 60},
+			{Second: 50, Tech: "Shifta", Level: 20, TpSpent: 25},
+		},
+	}
+
+	techUsage := getTechUsage(game, 2*time.Minute)
+	if len(techUsage) != 3 || techUsage[0].Tech != "Razonde" || techUsage[1].Tech != "Shifta" {
+		t.Fatalf("Expected techs ordered by TP spent but got %v", techUsage)
+	}
+	shifta := techUsage[1]
+	if shifta.Casts != 2 || shifta.TpSpent != 45 || shifta.MaxLevel != 20 || shifta.CastsPerMinute != "1.0" {
+		t.Errorf("Unexpected shifta usage %v", shifta)
+	}
+
+	castsBySecond := getTechCastsBySecond(game)
+	if len(castsBySecond) != 3 || castsBySecond[4].Tech != "Deband" {
+		t.Errorf("Expected the first cast in each second but got %v", castsBySecond)
+	}
+}
+
+func TestGetItemPickups(t *testing.T) {
+	game := &model.QuestRun{
+		ItemPickups: []model.ItemPickup{
+			{Second: 42, Display: "Monomate x2"},
+			{Second: 125, Display: "Spread Needle [0/0/0/0|0]", Rare: true},
+		},
+	}
+
+	pickups, rareCount := getItemPickups(game)
+	if len(pickups) != 2 || rareCount != 1 {
+		t.Fatalf("Expected 2 pickups with 1 rare but got %v with %v rare", pickups, rareCount)
+	}
+	if pickups[1].QuestTime != "2:05" || !pickups[1].Rare {
+		t.Errorf("Unexpected rare pickup %v", pickups[1])
+	}
+}
+
+func TestGetTimelineEvents_PhotonBlastsOnPbLine(t *testing.T) {
+	game := &model.QuestRun{
+		Events: []model.Event{
+			{Second: 0, Description: "Forest 1"},
+			{Second: 30, Description: "Photon Blast: Golla"},
+			{Second: 45, Description: "Forest 2"},
+		},
+		PhotonBlasts: []model.PhotonBlast{{Second: 30, Blast: "Golla"}},
+	}
+
+	events := getTimelineEvents(game)
+	if len(events) != 2 || events[1].Description != "Forest 2" {
+		t.Errorf("Expected only the floor events but got %v", events)
+	}
+	if blast := getPhotonBlastsBySecond(game)[30]; blast == nil || blast.Blast != "Golla" {
+		t.Errorf("Expected Golla at 30s but got %v", blast)
+	}
+}
+
+func TestGetWaves_StatusEffects(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	game := &model.QuestRun{
+		QuestStartTime: start,
+		QuestEndTime:   start.Add(30 * time.Second),
+		Monsters: map[int]model.Monster{
+			1: {Name: "Booma", Id: 1, UnitxtId: 1, SpawnTime: start, KilledTime: start.Add(5 * time.Second), FrozenTime: 2 * time.Second},
+			2: {Name: "Booma", Id: 2, UnitxtId: 1, SpawnTime: start, KilledTime: start.Add(6 * time.Second), FrozenTime: 1500 * time.Millisecond},
+		},
+	}
+
+	waves := getWaves(game)
+	if len(waves) != 1 || waves[0].Frozen != "3.500" || waves[0].Confused != "" {
+		t.Errorf("Expected 3.5s frozen and nothing else but got %+v", waves)
+	}
+}
+
+func TestFramesBySecond_WithFrameRate(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	// 4 frames a second, second 2 was missed
+	deltas := make([]model.DataFrameDelta, 0)
+	previous := model.DataFrame{}
+	for _, millis := range []int64{0, 250, 500, 750, 1000, 1250, 1500, 1750, 3000, 3250} {
+		hp := uint16(1000 + millis)
+		frame := model.DataFrame{TimeMillis: start + millis, HP: hp}
+		deltas = append(deltas, model.EncodeDataFrame(previous, frame))
+		previous = frame
+	}
+	game := &model.QuestRun{DataFrameRate: 4, DataFrameDeltas: deltas}
+
+	frames := framesBySecond(game.GetDataFrames())
+	expectedHp := []uint16{1000, 2000, 2000, 4000}
+	if len(frames) != len(expectedHp) {
+		t.Fatalf("Expected a frame for each of %v seconds but got %v", len(expectedHp), len(frames))
+	}
+	for second, hp := range expectedHp {
+		if frames[second].HP != hp {
+			t.Errorf("Expected hp %v at second %v but got %v", hp, second, frames[second].HP)
+		}
+	}
+
+	legacy := []model.DataFrame{{Time: 100, HP: 1}, {Time: 101, HP: 2}, {Time: 102, HP: 3}}
+	if frames := framesBySecond(legacy); len(frames) != 3 || frames[2].HP != 3 {
+		t.Errorf("Expected frames recorded once a second to be unchanged but got %v", frames)
+	}
+}
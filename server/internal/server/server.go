@@ -134,6 +134,9 @@ func (s *Server) Run() {
 	s.app.Get("/api/weapons", s.GetWeapons)
 	s.app.Post("/api/motd", s.PostMotd)
 	s.app.Post("/api/users/register", s.RegisterUser)
+	s.app.Post("/api/tokens", s.CreateApiToken)
+	s.app.Get("/api/tokens", s.GetApiTokens)
+	s.app.Delete("/api/tokens/:tokenId", s.RevokeApiToken)
 	s.indexTemplate = ensureParsed("./server/internal/templates/index.gohtml")
 	s.infoTemplate = ensureParsed("./server/internal/templates/info.gohtml")
 	s.playerTemplate = ensureParsed("./server/internal/templates/playerV2.gohtml")
@@ -427,7 +430,7 @@ func formatMap(game *model.QuestRun, data []model.DataFrame) []MapData {
 				playerData.Title = fmt.Sprintf("Player %d: %v", player+1, game.AllPlayers[player].Name)
 			}
 			playerData.Coordinates = append(playerData.Coordinates, []float32{location.X / 4, -location.Z / 4})
-			playerData.Time = append(playerData.Time, frame.Time*1000)
+			playerData.Time = append(playerData.Time, frame.Millis())
 			mapData.Movement[playerId] = playerData
 		}
 		// New location info
@@ -440,7 +443,7 @@ func formatMap(game *model.QuestRun, data []model.DataFrame) []MapData {
 				playerData.Title = fmt.Sprintf("Player %d: %v", playerIndexByGc[gc]+1, playerByGc[gc].Name)
 			}
 			playerData.Coordinates = append(playerData.Coordinates, []float32{location.X / 4, -location.Z / 4})
-			playerData.Time = append(playerData.Time, frame.Time*1000)
+			playerData.Time = append(playerData.Time, frame.Millis())
 			mapData.Movement[playerIndex] = playerData
 		}
 		for monster, location := range frame.MonsterLocation {
@@ -452,7 +455,7 @@ func formatMap(game *model.QuestRun, data []model.DataFrame) []MapData {
 				monsterData.Title = game.Monsters[monster].Name
 			}
 			monsterData.Coordinates = append(monsterData.Coordinates, []float32{location.X / 4, -location.Z / 4})
-			monsterData.Time = append(monsterData.Time, frame.Time*1000)
+			monsterData.Time = append(monsterData.Time, frame.Millis())
 			mapData.Movement[monsterId] = monsterData
 		}
 	}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/phelix-/psostats/v2/pkg/model"
+	"github.com/phelix-/psostats/v2/server/internal/userdb"
+)
+
+// Tokens are sent as "<id>.<secret>", the id finds the token and the secret is checked against its hash
+const tokenSeparator = "."
+
+// NewApiToken issues a token to userId, returning it along with the token string the client keeps.
+// The secret is random enough that a plain sha256 is safe to store, so checking it doesn't need bcrypt.
+func NewApiToken(userId string, name string) (userdb.ApiToken, string, error) {
+	idBytes := make([]byte, 9)
+	if _, err := rand.Read(idBytes); err != nil {
+		return userdb.ApiToken{}, "", err
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return userdb.ApiToken{}, "", err
+	}
+	id := hex.EncodeToString(idBytes)
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+	token := userdb.ApiToken{
+		Id:         id,
+		UserId:     userId,
+		SecretHash: hashTokenSecret(secret),
+		Name:       name,
+		Created:    time.Now(),
+	}
+	return token, id + tokenSeparator + secret, nil
+}
+
+func hashTokenSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// TokenSecretMatches checks the secret from a token string against the hash stored for the token
+func TokenSecretMatches(token userdb.ApiToken, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(token.SecretHash), []byte(hashTokenSecret(secret))) == 1
+}
+
+func getTokenFromBearerAuth(headerBytes []byte) (string, string, error) {
+	headerString := string(headerBytes)
+	if !strings.HasPrefix(headerString, "Bearer ") {
+		return "", "", errors.New("missing bearer auth header")
+	}
+	tokenSplit := strings.SplitN(strings.TrimPrefix(headerString, "Bearer "), tokenSeparator, 2)
+	if len(tokenSplit) != 2 || len(tokenSplit[0]) == 0 || len(tokenSplit[1]) == 0 {
+		return "", "", errors.New("malformed api token")
+	}
+	return tokenSplit[0], tokenSplit[1], nil
+}
+
+func (s *Server) verifyToken(headerBytes []byte) (bool, *userdb.User) {
+	tokenId, secret, err := getTokenFromBearerAuth(headerBytes)
+	if err != nil {
+		return false, nil
+	}
+	token, err := s.userDb.GetApiToken(tokenId)
+	if err != nil || token == nil || !TokenSecretMatches(*token, secret) {
+		return false, nil
+	}
+	userObject, err := s.userDb.GetUser(token.UserId)
+	if err != nil || userObject == nil {
+		return false, nil
+	}
+	return true, userObject
+}
+
+// CreateApiToken exchanges a user's password for a new api token. Only the password is accepted here so a leaked
+// token can't be used to issue more.
+func (s *Server) CreateApiToken(c *fiber.Ctx) error {
+	user, pass, err := getUserFromBasicAuth(c.Request().Header.Peek("Authorization"))
+	if err != nil || len(user) < 1 {
+		c.Status(401)
+		return nil
+	}
+	userObject, err := s.userDb.GetUser(user)
+	if err != nil || userObject == nil || !DoPasswordsMatch(userObject.Password, pass) {
+		c.Status(401)
+		return nil
+	}
+	var request model.CreateApiTokenRequest
+	if err := c.BodyParser(&request); err != nil {
+		c.Status(400)
+		return nil
+	}
+	token, tokenString, err := NewApiToken(userObject.Id, request.Name)
+	if err != nil {
+		return err
+	}
+	if err := s.userDb.CreateApiToken(token); err != nil {
+		return err
+	}
+	apiToken := toModelApiToken(token)
+	apiToken.Token = tokenString
+	return respondWithJson(apiToken, c)
+}
+
+// GetApiTokens lists the caller's tokens without their secrets
+func (s *Server) GetApiTokens(c *fiber.Ctx) error {
+	authorized, user := s.verifyAuth(&c.Request().Header)
+	if !authorized {
+		c.Status(401)
+		return nil
+	}
+	tokens, err := s.userDb.GetApiTokens(user.Id)
+	if err != nil {
+		return err
+	}
+	apiTokens := make([]model.ApiToken, len(tokens))
+	for i, token := range tokens {
+		apiTokens[i] = toModelApiToken(token)
+	}
+	return respondWithJson(apiTokens, c)
+}
+
+// RevokeApiToken deletes one of the caller's tokens, it stops working straight away
+func (s *Server) RevokeApiToken(c *fiber.Ctx) error {
+	authorized, user := s.verifyAuth(&c.Request().Header)
+	if !authorized {
+		c.Status(401)
+		return nil
+	}
+	token, err := s.userDb.GetApiToken(c.Params("tokenId"))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UserId != user.Id {
+		c.Status(404)
+		return nil
+	}
+	return s.userDb.DeleteApiToken(*token)
+}
+
+func toModelApiToken(token userdb.ApiToken) model.ApiToken {
+	return model.ApiToken{
+		Id:      token.Id,
+		Name:    token.Name,
+		Created: token.Created,
+	}
+}
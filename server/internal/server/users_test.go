@@ -4,6 +4,7 @@ import (
 	"github.com/phelix-/psostats/v2/pkg/model"
 	"github.com/phelix-/psostats/v2/server/internal/server"
 	"log"
+	"strings"
 	"testing"
 	"time"
 )
@@ -13,18 +14,38 @@ func Test_hashPassword(t *testing.T) {
 	log.Printf("%v - %v", passwordIn, server.HashPassword(passwordIn))
 }
 
+func Test_apiTokenSecretMatches(t *testing.T) {
+	token, tokenString, err := server.NewApiToken("user", "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserId != "user" || token.Name != "laptop" {
+		t.Errorf("token not issued to user: %+v", token)
+	}
+	secret := strings.TrimPrefix(tokenString, token.Id+".")
+	if secret == tokenString || strings.Contains(token.SecretHash, secret) {
+		t.Errorf("expected <id>.<secret> with only a hash of the secret stored, got %v", tokenString)
+	}
+	if !server.TokenSecretMatches(token, secret) {
+		t.Error("secret should match")
+	}
+	if server.TokenSecretMatches(token, secret+"x") {
+		t.Error("wrong secret matched")
+	}
+}
+
 func Test_gamesMatch(t *testing.T) {
-	a := model.QuestRun{GuildCard: "u1", UserName: "u1", QuestStartTime: time.Now()}
-	b := model.QuestRun{GuildCard: "u2", UserName: "u2", QuestStartTime: time.Now()}
+	a := model.QuestRun{GuildCard: "u1", UserName: "u1", SubmittedTime: time.Now()}
+	b := model.QuestRun{GuildCard: "u2", UserName: "u2", SubmittedTime: time.Now()}
 
 	if !server.GamesMatch(a, b) {
 		t.Error("match")
 	}
-	b.QuestStartTime = time.Now().Add(45 * time.Second)
+	b.SubmittedTime = time.Now().Add(45 * time.Second)
 	if server.GamesMatch(a, b) {
 		t.Error("match")
 	}
-	b.QuestStartTime = time.Now().Add(-45 * time.Second)
+	b.SubmittedTime = time.Now().Add(-45 * time.Second)
 	if server.GamesMatch(a, b) {
 		t.Error("match")
 	}
@@ -43,7 +64,8 @@ func Test_cmodeRegex(t *testing.T) {
 	if server.IsLeaderboardCandidate(questRun) {
 		t.Error("ma1c normal")
 	}
-	questRun = model.QuestRun{QuestName: "ma1c", Difficulty: "Ultimate", QuestComplete: true}
+	questRun = model.QuestRun{QuestName: "ma1c", Difficulty: "Ultimate", QuestComplete: true,
+		Client: model.ClientInfo{VersionMajor: 1, VersionMinor: 4, VersionPatch: 1}}
 	if !server.IsLeaderboardCandidate(questRun) {
 		t.Error("ma1c ult")
 	}
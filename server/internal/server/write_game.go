@@ -35,7 +35,11 @@ func getUserFromBasicAuth(headerBytes []byte) (string, string, error) {
 	}
 }
 
+// verifyAuth accepts an api token or, for clients that haven't switched to tokens yet, the user's password
 func (s *Server) verifyAuth(header *fasthttp.RequestHeader) (bool, *userdb.User) {
+	if strings.HasPrefix(string(header.Peek("Authorization")), "Bearer ") {
+		return s.verifyToken(header.Peek("Authorization"))
+	}
 	user, pass, err := getUserFromBasicAuth(header.Peek("Authorization"))
 	if err != nil || len(user) < 1 {
 		return false, nil
@@ -325,7 +329,7 @@ type Field struct {
 }
 
 func isRankedByScore(questRun model.QuestRun) bool {
-	if questRun.QuestName == "Endless: Episode 1" || "Endless: Episode 2" {
+	if questRun.QuestName == "Endless: Episode 1" || questRun.QuestName == "Endless: Episode 2" {
 		return true
 	} else {
 		return false
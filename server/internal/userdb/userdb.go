@@ -1,28 +1,45 @@
 package userdb
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
 const (
-	PlayersTable          = "players"
+	// Keyed by Id
+	PlayersTable = "players"
+	// Keyed by DiscordId
 	PlayersByDiscordTable = "players_by_discord"
+	// Keyed by Gc, maps a guild card to the Id of the player it belongs to
+	GcToPlayerTable = "gc_to_player"
+	// Keyed by Id
+	ApiTokensTable = "api_tokens"
+	// Keyed by UserId and Id to list a user's tokens
+	ApiTokensByUserTable = "api_tokens_by_user"
 )
 
 type User struct {
-	Id          string `json:"id" dynamodbav:"Id"`
-	Password    string `json:"password" dynamodbav:"Password"`
-	DiscordName string `json:"discord_name" dynamodbav:"DiscordName"`
-	DiscordId   string `json:"discord_id" dynamodbav:"DiscordId"`
-	Admin       bool   `json:"admin" dynamodbav:"Admin"`
+	Id          string   `json:"id" dynamodbav:"Id"`
+	Gcs         []string `json:"gcs" dynamodbav:"Gcs"`
+	Password    string   `json:"password" dynamodbav:"Password"`
+	DiscordName string   `json:"discord_name" dynamodbav:"DiscordName"`
+	DiscordId   string   `json:"discord_id" dynamodbav:"DiscordId"`
+	Admin       bool     `json:"admin" dynamodbav:"Admin"`
 }
 
 type UserDb interface {
 	GetUser(userName string) (*User, error)
 	GetUserByDiscordId(discordId string) (*User, error)
 	CreateUser(user User) error
+	AddGcToUser(userName string, gc string) error
+	GetUsernameByGc(gc string) (string, error)
+	CreateApiToken(token ApiToken) error
+	GetApiToken(tokenId string) (*ApiToken, error)
+	GetApiTokens(userName string) ([]ApiToken, error)
+	DeleteApiToken(token ApiToken) error
 }
 
 type DynamoUserDb struct {
@@ -82,6 +99,9 @@ func (d DynamoUserDb) CreateUser(user User) error {
 	if err != nil {
 		return err
 	}
+	if len(user.DiscordId) == 0 {
+		return nil
+	}
 	userByDiscord := &dynamodb.PutItemInput{
 		Item:      marshalled,
 		TableName: aws.String(PlayersByDiscordTable),
@@ -93,3 +113,128 @@ func (d DynamoUserDb) CreateUser(user User) error {
 
 	return nil
 }
+
+// AddGcToUser appends gc to the user's guild cards and maps it back to the user, both or neither are written
+func (d DynamoUserDb) AddGcToUser(userName string, gc string) error {
+	_, err := d.dynamoClient.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Update: &dynamodb.Update{
+				TableName:        aws.String(PlayersTable),
+				Key:              map[string]*dynamodb.AttributeValue{"Id": {S: aws.String(userName)}},
+				UpdateExpression: aws.String("SET Gcs = list_append(if_not_exists(Gcs, :empty), :gc)"),
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":empty": {L: []*dynamodb.AttributeValue{}},
+					":gc":    {L: []*dynamodb.AttributeValue{{S: aws.String(gc)}}},
+				},
+			}},
+			{Put: &dynamodb.Put{
+				TableName: aws.String(GcToPlayerTable),
+				Item: map[string]*dynamodb.AttributeValue{
+					"Gc": {S: aws.String(gc)},
+					"Id": {S: aws.String(userName)},
+				},
+			}},
+		},
+	})
+	return err
+}
+
+// GetUsernameByGc is the Id of the player gc was added to, empty when it hasn't been
+func (d DynamoUserDb) GetUsernameByGc(gc string) (string, error) {
+	primaryKey := dynamodb.AttributeValue{
+		S: aws.String(gc),
+	}
+	getItem := dynamodb.GetItemInput{
+		TableName: aws.String(GcToPlayerTable),
+		Key:       map[string]*dynamodb.AttributeValue{"Gc": &primaryKey},
+	}
+	item, err := d.dynamoClient.GetItem(&getItem)
+	if err != nil || item.Item == nil || item.Item["Id"] == nil {
+		return "", err
+	}
+	return aws.StringValue(item.Item["Id"].S), nil
+}
+
+// ApiToken lets a client authenticate without its password, only a hash of the token's secret is stored.
+// Revoking a token deletes it.
+type ApiToken struct {
+	Id         string    `json:"id" dynamodbav:"Id"`
+	UserId     string    `json:"user_id" dynamodbav:"UserId"`
+	SecretHash string    `json:"-" dynamodbav:"SecretHash"`
+	Name       string    `json:"name" dynamodbav:"Name"`
+	Created    time.Time `json:"created" dynamodbav:"Created"`
+}
+
+// CreateApiToken writes the token to both token tables in one transaction, so a token is never usable without being
+// listed or listed without being usable
+func (d DynamoUserDb) CreateApiToken(token ApiToken) error {
+	marshalled, err := dynamodbattribute.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+	_, err = d.dynamoClient.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Put: &dynamodb.Put{Item: marshalled, TableName: aws.String(ApiTokensTable)}},
+			{Put: &dynamodb.Put{Item: marshalled, TableName: aws.String(ApiTokensByUserTable)}},
+		},
+	})
+	return err
+}
+
+func (d DynamoUserDb) GetApiToken(tokenId string) (*ApiToken, error) {
+	token := ApiToken{}
+	primaryKey := dynamodb.AttributeValue{
+		S: aws.String(tokenId),
+	}
+	getItem := dynamodb.GetItemInput{
+		TableName: aws.String(ApiTokensTable),
+		Key:       map[string]*dynamodb.AttributeValue{"Id": &primaryKey},
+	}
+	item, err := d.dynamoClient.GetItem(&getItem)
+	if err != nil || item.Item == nil {
+		return nil, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(item.Item, &token)
+	return &token, err
+}
+
+// GetApiTokens lists the tokens issued to userName
+func (d DynamoUserDb) GetApiTokens(userName string) ([]ApiToken, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(ApiTokensByUserTable),
+		KeyConditionExpression: aws.String("UserId = :userId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":userId": {S: aws.String(userName)},
+		},
+	}
+	result, err := d.dynamoClient.Query(&query)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]ApiToken, 0)
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &tokens)
+	return tokens, err
+}
+
+// DeleteApiToken removes the token from both token tables in one transaction
+func (d DynamoUserDb) DeleteApiToken(token ApiToken) error {
+	_, err := d.dynamoClient.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Delete: &dynamodb.Delete{
+				TableName: aws.String(ApiTokensTable),
+				Key: map[string]*dynamodb.AttributeValue{
+					"Id": {S: aws.String(token.Id)},
+				},
+			}},
+			{Delete: &dynamodb.Delete{
+				TableName: aws.String(ApiTokensByUserTable),
+				Key: map[string]*dynamodb.AttributeValue{
+					"UserId": {S: aws.String(token.UserId)},
+					"Id":     {S: aws.String(token.Id)},
+				},
+			}},
+		},
+	})
+	return err
+}
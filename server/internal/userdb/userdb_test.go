@@ -1,3 +1,6 @@
+//go:build dynamodb
+
+// Needs the dynamodb-local from db_tests/docker-compose.yml, run with -tags dynamodb
 package userdb_test
 
 import (
@@ -10,6 +13,7 @@ import (
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func getFixture() (*userdb.DynamoUserDb, error) {
@@ -41,6 +45,18 @@ func getFixture() (*userdb.DynamoUserDb, error) {
 			return nil, err
 		}
 	}
+	if _, exists := tables[userdb.ApiTokensTable]; !exists {
+		err = CreateApiTokensTable(dynamoClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, exists := tables[userdb.ApiTokensByUserTable]; !exists {
+		err = CreateApiTokensByUserTable(dynamoClient)
+		if err != nil {
+			return nil, err
+		}
+	}
 	instance := userdb.DynamoInstance(dynamoClient)
 	return &instance, nil
 }
@@ -48,7 +64,7 @@ func getFixture() (*userdb.DynamoUserDb, error) {
 func TestAwsUserDb_TryEverythingOnce(t *testing.T) {
 	fixture, err := getFixture()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	user := userdb.User{
 		Id:       fmt.Sprintf("test%v", rand.Int()),
@@ -95,6 +111,55 @@ func TestAwsUserDb_TryEverythingOnce(t *testing.T) {
 	}
 }
 
+func TestAwsUserDb_ApiTokens(t *testing.T) {
+	fixture, err := getFixture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := userdb.ApiToken{
+		Id:         fmt.Sprintf("token%v", rand.Int()),
+		UserId:     fmt.Sprintf("test%v", rand.Int()),
+		SecretHash: "hash",
+		Name:       "laptop",
+		Created:    time.Now().UTC().Truncate(time.Second),
+	}
+	if err = fixture.CreateApiToken(token); err != nil {
+		t.Fatal(err)
+	}
+	fromDb, err := fixture.GetApiToken(token.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if fromDb == nil || !reflect.DeepEqual(token, *fromDb) {
+		t.Errorf("token returned from db was modified: %v", fromDb)
+	}
+	tokens, err := fixture.GetApiTokens(token.UserId)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tokens) != 1 || tokens[0].Id != token.Id {
+		t.Errorf("expected the token to be listed for its user but got %v", tokens)
+	}
+
+	if err = fixture.DeleteApiToken(token); err != nil {
+		t.Fatal(err)
+	}
+	fromDb, err = fixture.GetApiToken(token.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if fromDb != nil {
+		t.Errorf("deleted token still usable: %v", fromDb)
+	}
+	tokens, err = fixture.GetApiTokens(token.UserId)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("deleted token still listed: %v", tokens)
+	}
+}
+
 func CreatePlayersTable(dynamoClient *dynamodb.DynamoDB) error {
 	provisionedThroughput := dynamodb.ProvisionedThroughput{
 		ReadCapacityUnits:  aws.Int64(1),
@@ -140,3 +205,57 @@ func CreateGcToPlayerTable(dynamoClient *dynamodb.DynamoDB) error {
 	_, err := dynamoClient.CreateTable(&createTableInput)
 	return err
 }
+
+func CreateApiTokensTable(dynamoClient *dynamodb.DynamoDB) error {
+	provisionedThroughput := dynamodb.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(1),
+		WriteCapacityUnits: aws.Int64(1),
+	}
+	attributeDefinition := dynamodb.AttributeDefinition{
+		AttributeName: aws.String("Id"),
+		AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+	}
+	keySchemaElement := dynamodb.KeySchemaElement{
+		AttributeName: aws.String("Id"),
+		KeyType:       aws.String(dynamodb.KeyTypeHash),
+	}
+	createTableInput := dynamodb.CreateTableInput{
+		AttributeDefinitions:  []*dynamodb.AttributeDefinition{&attributeDefinition},
+		KeySchema:             []*dynamodb.KeySchemaElement{&keySchemaElement},
+		TableName:             aws.String(userdb.ApiTokensTable),
+		ProvisionedThroughput: &provisionedThroughput,
+	}
+	_, err := dynamoClient.CreateTable(&createTableInput)
+	return err
+}
+
+func CreateApiTokensByUserTable(dynamoClient *dynamodb.DynamoDB) error {
+	provisionedThroughput := dynamodb.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(1),
+		WriteCapacityUnits: aws.Int64(1),
+	}
+	userIdAttribute := dynamodb.AttributeDefinition{
+		AttributeName: aws.String("UserId"),
+		AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+	}
+	idAttribute := dynamodb.AttributeDefinition{
+		AttributeName: aws.String("Id"),
+		AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+	}
+	hashKey := dynamodb.KeySchemaElement{
+		AttributeName: aws.String("UserId"),
+		KeyType:       aws.String(dynamodb.KeyTypeHash),
+	}
+	rangeKey := dynamodb.KeySchemaElement{
+		AttributeName: aws.String("Id"),
+		KeyType:       aws.String(dynamodb.KeyTypeRange),
+	}
+	createTableInput := dynamodb.CreateTableInput{
+		AttributeDefinitions:  []*dynamodb.AttributeDefinition{&userIdAttribute, &idAttribute},
+		KeySchema:             []*dynamodb.KeySchemaElement{&hashKey, &rangeKey},
+		TableName:             aws.String(userdb.ApiTokensByUserTable),
+		ProvisionedThroughput: &provisionedThroughput,
+	}
+	_, err := dynamoClient.CreateTable(&createTableInput)
+	return err
+}